@@ -0,0 +1,71 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/spinnaker/spin/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+// GetApplication returns the named application.
+func (c *Client) GetApplication(ctx context.Context, name string) (map[string]interface{}, error) {
+	app, resp, err := c.gate.ApplicationControllerApi.GetApplicationUsingGET(c.requestContext(ctx), name, map[string]interface{}{})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, util.NewNotFoundError("Application '%s' not found\n", name)
+	}
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError("Encountered an error getting application", resp, err)
+	}
+	return app, nil
+}
+
+// ListApplications returns every application.
+func (c *Client) ListApplications(ctx context.Context) ([]interface{}, error) {
+	apps, resp, err := c.gate.ApplicationControllerApi.GetAllApplicationsUsingGET(c.requestContext(ctx), map[string]interface{}{})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError("Encountered an error listing applications", resp, err)
+	}
+	return apps, nil
+}
+
+// SaveApplication creates or updates the application and waits for the
+// change to complete. app must include "name", "email" and "cloudProviders".
+func (c *Client) SaveApplication(ctx context.Context, app map[string]interface{}) error {
+	task := map[string]interface{}{
+		"job":         []interface{}{map[string]interface{}{"type": "createApplication", "application": app}},
+		"application": app["name"],
+		"description": fmt.Sprintf("Create Application: %s", app["name"]),
+	}
+	return c.runTask(ctx, task, "Encountered an error saving application")
+}
+
+// DeleteApplication submits a task deleting the named application. It
+// doesn't wait for the task to complete.
+func (c *Client) DeleteApplication(ctx context.Context, name string) error {
+	task := map[string]interface{}{
+		"job": []interface{}{map[string]interface{}{
+			"type":        "deleteApplication",
+			"application": map[string]interface{}{"name": name},
+		}},
+		"application": name,
+		"description": fmt.Sprintf("Delete Application: %s", name),
+	}
+	_, err := c.submitTask(ctx, task, "Encountered an error deleting application")
+	return err
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spinnaker/spin/util"
+)
+
+// PipelineDeleted is the action for pipelines pruned by ApplyPipelines.
+const PipelineDeleted = "deleted"
+
+// DefaultApplyConcurrency is the number of pipelines ApplyPipelines saves at once.
+const DefaultApplyConcurrency = 4
+
+// PipelineChange is a planned or applied change to one pipeline.
+type PipelineChange struct {
+	SavePipelineResult
+	// Source names where the pipeline was read from, such as its file.
+	Source string `json:"source,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// err is the failure behind Error, kept for its exit code.
+	err error
+}
+
+// PipelineSource is a pipeline to apply, with where it was read from.
+type PipelineSource struct {
+	Source   string
+	Pipeline map[string]interface{}
+}
+
+// PlanPipelines compares the pipelines with those deployed in their
+// applications, deciding for each whether it would be created, updated or
+// unchanged. With prune, deployed pipelines in those applications that are
+// not among the given pipelines are planned for deletion. Pipelines are
+// validated without the disabled lint rules. Changes are ordered by
+// application and name.
+func (c *Client) PlanPipelines(ctx context.Context, pipelines []PipelineSource, prune bool, disabledLintRules ...string) ([]*PipelineChange, error) {
+	byApplication := map[string][]PipelineSource{}
+	seen := map[string]string{}
+	for _, p := range pipelines {
+		if err := ValidatePipeline(p.Pipeline, disabledLintRules...); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Source, err)
+		}
+		// Checked even when the required-keys rule is disabled, since
+		// pipelines are grouped by application.
+		application, _ := p.Pipeline["application"].(string)
+		name, _ := p.Pipeline["name"].(string)
+		if application == "" || name == "" {
+			return nil, util.NewValidationError("%s: pipeline must have an application and a name", p.Source)
+		}
+		key := application + "/" + name
+		if other, exists := seen[key]; exists {
+			return nil, util.NewValidationError("Pipeline %s in application %s is defined in both %s and %s", name, application, other, p.Source)
+		}
+		seen[key] = p.Source
+		byApplication[application] = append(byApplication[application], p)
+	}
+
+	changes := []*PipelineChange{}
+	for application, sources := range byApplication {
+		deployed, err := c.ListPipelines(ctx, application)
+		if err != nil {
+			return nil, err
+		}
+		deployedByName := map[string]map[string]interface{}{}
+		for _, d := range deployed {
+			if pipeline, ok := d.(map[string]interface{}); ok {
+				if name, ok := pipeline["name"].(string); ok {
+					deployedByName[name] = pipeline
+				}
+			}
+		}
+
+		for _, p := range sources {
+			name := fmt.Sprintf("%v", p.Pipeline["name"])
+			result, err := planSave(p.Pipeline, deployedByName[name])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p.Source, err)
+			}
+			delete(deployedByName, name)
+			changes = append(changes, &PipelineChange{SavePipelineResult: *result, Source: p.Source})
+		}
+
+		if prune {
+			for name, pipeline := range deployedByName {
+				change := &PipelineChange{SavePipelineResult: SavePipelineResult{
+					Action:      PipelineDeleted,
+					Application: application,
+					Name:        name,
+					Previous:    pipeline,
+				}}
+				change.Id, _ = pipeline["id"].(string)
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Application != changes[j].Application {
+			return changes[i].Application < changes[j].Application
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// ApplyPipelines makes the planned changes, at most concurrency at a time.
+// Every change is attempted; failures are recorded in the change's Error
+// and summarized in the returned error.
+func (c *Client) ApplyPipelines(ctx context.Context, changes []*PipelineChange, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	attempted := 0
+	for _, change := range changes {
+		if change.Action == PipelineUnchanged {
+			continue
+		}
+		attempted++
+		wg.Add(1)
+		go func(change *PipelineChange) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			var err error
+			if change.Action == PipelineDeleted {
+				err = c.DeletePipeline(ctx, change.Application, change.Name)
+			} else {
+				err = c.postPipeline(ctx, &change.SavePipelineResult)
+			}
+			if err != nil {
+				change.err = err
+				change.Error = err.Error()
+			}
+		}(change)
+	}
+	wg.Wait()
+
+	failures := []string{}
+	code := util.ExitOK
+	for _, change := range changes {
+		if change.err == nil {
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s/%s: %s", change.Application, change.Name, change.Error))
+		// Exit with the failures' code if they agree, otherwise a general error.
+		if changeCode := util.ExitCode(change.err); code == util.ExitOK {
+			code = changeCode
+		} else if code != changeCode {
+			code = util.ExitError
+		}
+	}
+	if len(failures) > 0 {
+		return &util.CodedError{Code: code, Err: fmt.Errorf("%d of %d pipeline changes failed:\n  - %s",
+			len(failures), attempted, strings.Join(failures, "\n  - "))}
+	}
+	return ctx.Err()
+}
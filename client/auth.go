@@ -0,0 +1,32 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/spinnaker/spin/gateclient"
+)
+
+// ListServiceAccounts returns the service accounts the authenticated user
+// may run pipelines as.
+func (c *Client) ListServiceAccounts(ctx context.Context) ([]interface{}, error) {
+	serviceAccounts, resp, err := c.gate.AuthControllerApi.GetServiceAccountsUsingGET(c.requestContext(ctx))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError("Encountered an error listing service accounts", resp, err)
+	}
+	return serviceAccounts, nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package client is a Go library for the operations behind the spin
+// commands: reading and saving applications, pipelines and pipeline
+// templates, and executing pipelines. It doesn't depend on cobra or write
+// to the terminal, so tools can embed it; the spin commands are thin
+// wrappers around it.
+//
+//	c, err := client.New(ctx, client.Options{GateEndpoint: "https://gate.example.com"})
+//	if err != nil {
+//		return err
+//	}
+//	ids, err := c.ExecutePipeline(ctx, "app", "deploy", client.ExecuteOptions{})
+//
+// Failed calls return a *GateError describing Gate's response, or a
+// *TaskError describing a failed Orca task.
+package client
+
+import (
+	"context"
+
+	gate "github.com/spinnaker/spin/gateapi"
+	"github.com/spinnaker/spin/gateclient"
+)
+
+// Options configures the connection to Gate: the spin config file,
+// endpoint, proxy, retries and where prompts and warnings go.
+type Options = gateclient.Options
+
+// GateError describes a failed call to Gate.
+type GateError = gateclient.GateError
+
+// TaskError describes an Orca task that finished without succeeding.
+type TaskError = gateclient.TaskError
+
+// Client calls Gate on behalf of spin commands and embedding tools.
+type Client struct {
+	gate *gateclient.GatewayClient
+}
+
+// New creates a Client authenticated as described by the spin config.
+// Requests are aborted when ctx is canceled.
+func New(ctx context.Context, options Options) (*Client, error) {
+	gateClient, err := gateclient.New(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromGateClient(gateClient), nil
+}
+
+// NewFromGateClient wraps an already configured Gate client.
+func NewFromGateClient(gateClient *gateclient.GatewayClient) *Client {
+	return &Client{gate: gateClient}
+}
+
+// Gate returns the generated Gate API client, for calls this package
+// doesn't cover.
+func (c *Client) Gate() *gateclient.GatewayClient {
+	return c.gate
+}
+
+// requestContext carries the client's credentials over to ctx, so callers
+// can pass their own contexts for cancellation.
+func (c *Client) requestContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return c.gate.Context
+	}
+	if auth := c.gate.Context.Value(gate.ContextBasicAuth); auth != nil && ctx.Value(gate.ContextBasicAuth) == nil {
+		ctx = context.WithValue(ctx, gate.ContextBasicAuth, auth)
+	}
+	return ctx
+}
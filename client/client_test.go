@@ -0,0 +1,348 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+// newTestClient creates a Client for the test server without reading the
+// user's spin config or writing to the terminal.
+func newTestClient(t *testing.T, ts *httptest.Server) *Client {
+	ui, err := util.NewUI(false, util.ColorNever, "", strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("Could not create UI: %v", err)
+	}
+	c, err := New(context.Background(), Options{
+		ConfigPath:   "/dev/null",
+		GateEndpoint: ts.URL,
+		UI:           ui,
+	})
+	if err != nil {
+		t.Fatalf("Could not create client: %v", err)
+	}
+	return c
+}
+
+func TestSavePipeline(t *testing.T) {
+	var saved map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&saved)
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts)
+	pipeline := map[string]interface{}{"name": "one", "application": "app", "stages": []interface{}{}}
+	result, err := c.SavePipeline(context.Background(), pipeline)
+	if err != nil {
+		t.Fatalf("SavePipeline failed: %v", err)
+	}
+	if saved["name"] != "one" {
+		t.Fatalf("Expected the pipeline to be posted, got %v", saved)
+	}
+	if result.Action != PipelineCreated {
+		t.Fatalf("Expected the pipeline to be created, got %s", result.Action)
+	}
+}
+
+func TestSavePipeline_update(t *testing.T) {
+	existing := `{"id": "abc", "index": 2, "name": "one", "application": "app", "stages": [], "updateTs": "1526578883109"}`
+	var saved map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&saved)
+			return
+		}
+		fmt.Fprintln(w, existing)
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts)
+	result, err := c.SavePipeline(context.Background(), map[string]interface{}{"name": "one", "application": "app", "stages": []interface{}{}})
+	if err != nil {
+		t.Fatalf("SavePipeline failed: %v", err)
+	}
+	if result.Action != PipelineUnchanged || result.Id != "abc" || saved != nil {
+		t.Fatalf("Expected an unchanged pipeline not to be saved, got %+v and %v", result, saved)
+	}
+
+	result, err = c.SavePipeline(context.Background(), map[string]interface{}{"name": "one", "application": "app", "stages": []interface{}{}, "limitConcurrent": true})
+	if err != nil {
+		t.Fatalf("SavePipeline failed: %v", err)
+	}
+	if result.Action != PipelineUpdated || result.Id != "abc" {
+		t.Fatalf("Expected pipeline abc to be updated, got %+v", result)
+	}
+	if saved["id"] != "abc" || saved["index"] != float64(2) {
+		t.Fatalf("Expected the existing id and index to be reused, got %v", saved)
+	}
+}
+
+func TestSavePipeline_generatedIds(t *testing.T) {
+	existing := `{"id": "abc", "index": 2, "name": "one", "application": "app",
+	  "stages": [{"id": "generated", "refId": "1", "type": "wait", "name": "Wait", "requisiteStageRefIds": []}]}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			t.Errorf("Unchanged pipeline should not be saved")
+			return
+		}
+		fmt.Fprintln(w, existing)
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts)
+	pipeline := map[string]interface{}{"name": "one", "application": "app", "stages": []interface{}{
+		map[string]interface{}{"refId": "1", "type": "wait", "name": "Wait", "requisiteStageRefIds": []interface{}{}},
+	}}
+	result, err := c.SavePipeline(context.Background(), pipeline)
+	if err != nil {
+		t.Fatalf("SavePipeline failed: %v", err)
+	}
+	if result.Action != PipelineUnchanged {
+		t.Fatalf("Expected ids Front50 generated to be ignored, got %s", result.Action)
+	}
+}
+
+func TestGetPipeline_emptyResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts)
+	_, err := c.GetPipeline(context.Background(), "app", "missing")
+	if util.ExitCode(err) != util.ExitNotFound {
+		t.Fatalf("Expected an empty response to mean not found, got %v", err)
+	}
+}
+
+func TestSavePipeline_invalid(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Invalid pipeline should not be sent to Gate")
+	}))
+	defer ts.Close()
+
+	c := newTestClient(t, ts)
+	_, err := c.SavePipeline(context.Background(), map[string]interface{}{"template": map[string]interface{}{"source": "x"}})
+	if util.ExitCode(err) != util.ExitInvalid {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	for _, expected := range []string{"'name'", "'application'", "'schema'"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to mention %s, got: %s", expected, err)
+		}
+	}
+}
+
+func TestExecutePipeline_wait(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.Handle("/pipelines/app/one", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintln(w, "{}")
+	}))
+	mux.Handle("/applications/app/executions/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[{"id": "exec-1"}]`)
+	}))
+	mux.Handle("/pipelines/exec-1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		status := "RUNNING"
+		if polls > 1 {
+			status = "SUCCEEDED"
+		}
+		fmt.Fprintf(w, `{"id": "exec-1", "status": %q}`, status)
+	}))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := newTestClient(t, ts)
+	ids, err := c.ExecutePipeline(context.Background(), "app", "one", ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("ExecutePipeline failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "exec-1" {
+		t.Fatalf("Expected execution exec-1, got %v", ids)
+	}
+
+	statuses := []string{}
+	_, err = c.WaitForExecution(context.Background(), ids[0], WaitOptions{
+		PollInterval: 1,
+		OnStatus:     func(status string) { statuses = append(statuses, status) },
+	})
+	if err != nil {
+		t.Fatalf("WaitForExecution failed: %v", err)
+	}
+	if strings.Join(statuses, ",") != "RUNNING,SUCCEEDED" {
+		t.Fatalf("Expected RUNNING then SUCCEEDED, got %v", statuses)
+	}
+}
+
+func TestSaveApplication_taskFailed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/tasks", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ref": "/tasks/task-1"}`)
+	}))
+	mux.Handle("/tasks/task-1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"id": "task-1", "status": "TERMINAL", "variables": [{"key": "exception", "value": {"details": {"errors": ["Application 'app' already exists"]}}}]}`)
+	}))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := newTestClient(t, ts)
+	err := c.SaveApplication(context.Background(), map[string]interface{}{"name": "app", "email": "owner@example.com"})
+	taskErr, ok := err.(*TaskError)
+	if !ok {
+		t.Fatalf("Expected a TaskError, got %T: %v", err, err)
+	}
+	if taskErr.TaskId != "task-1" || len(taskErr.Errors) != 1 {
+		t.Fatalf("Expected the task's errors, got %+v", taskErr)
+	}
+}
+
+func TestCopiedPipeline(t *testing.T) {
+	pipeline := map[string]interface{}{
+		"id":          "p1",
+		"index":       2,
+		"application": "sandbox",
+		"name":        "deploy",
+		"triggers":    []interface{}{map[string]interface{}{"type": "docker", "account": "sandbox-registry"}},
+		"stages": []interface{}{
+			map[string]interface{}{"refId": "1", "type": "deploy", "clusters": []interface{}{
+				map[string]interface{}{"account": "sandbox-aws", "application": "sandbox"},
+			}},
+			map[string]interface{}{"refId": "2", "type": "runJob", "credentials": "sandbox-k8s", "accounts": []interface{}{"sandbox-k8s", "shared"}},
+		},
+	}
+	copied, err := CopiedPipeline(pipeline, "prod", CopyOptions{
+		NewName:  "deploy-prod",
+		Accounts: map[string]string{"sandbox-registry": "prod-registry", "sandbox-aws": "prod-aws", "sandbox-k8s": "prod-k8s"},
+	})
+	if err != nil {
+		t.Fatalf("CopiedPipeline failed: %v", err)
+	}
+
+	expected := `{"application":"prod","name":"deploy-prod",` +
+		`"stages":[{"clusters":[{"account":"prod-aws","application":"sandbox"}],"refId":"1","type":"deploy"},` +
+		`{"accounts":["prod-k8s","shared"],"credentials":"prod-k8s","refId":"2","type":"runJob"}],` +
+		`"triggers":[{"account":"prod-registry","type":"docker"}]}`
+	actual, _ := json.Marshal(copied)
+	if string(actual) != expected {
+		t.Fatalf("Expected %s, got %s", expected, actual)
+	}
+	if pipeline["application"] != "sandbox" || pipeline["id"] != "p1" {
+		t.Fatalf("Expected the source pipeline to be unchanged, got %v", pipeline)
+	}
+}
+
+func TestPipelineQuery_Matches(t *testing.T) {
+	var pipeline map[string]interface{}
+	json.Unmarshal([]byte(`{
+  "application": "app",
+  "name": "deploy",
+  "triggers": [{"type": "cron", "cronExpression": "0 0 * * * ?"}],
+  "stages": [
+    {"refId": "1", "type": "deployManifest", "account": "prod-k8s"},
+    {"refId": "2", "type": "webhook", "url": "https://hooks.example.com/deployed"}
+  ]
+}`), &pipeline)
+
+	tests := []struct {
+		name     string
+		query    PipelineQuery
+		expected bool
+	}{
+		{"empty", PipelineQuery{}, true},
+		{"stage type", PipelineQuery{StageTypes: []string{"bake", "webhook"}}, true},
+		{"missing stage type", PipelineQuery{StageTypes: []string{"bake"}}, false},
+		{"account", PipelineQuery{Accounts: []string{"prod-k8s"}}, true},
+		{"missing account", PipelineQuery{Accounts: []string{"staging-k8s"}}, false},
+		{"trigger type", PipelineQuery{TriggerTypes: []string{"cron"}}, true},
+		{"missing trigger type", PipelineQuery{TriggerTypes: []string{"git"}}, false},
+		{"contains", PipelineQuery{Contains: []string{"HOOKS.example"}}, true},
+		{"missing content", PipelineQuery{Contains: []string{"slack"}}, false},
+		{"jsonpath", PipelineQuery{JsonPaths: []string{`{.stages[?(@.type=="webhook")].url}`}}, true},
+		{"missing jsonpath", PipelineQuery{JsonPaths: []string{`{.stages[?(@.type=="bake")]}`}}, false},
+		{"all", PipelineQuery{StageTypes: []string{"webhook"}, Accounts: []string{"prod-k8s"}, TriggerTypes: []string{"git"}}, false},
+	}
+	for _, test := range tests {
+		actual, err := test.query.Matches(pipeline)
+		if err != nil {
+			t.Fatalf("%s: Matches failed: %v", test.name, err)
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+
+	if _, err := (PipelineQuery{JsonPaths: []string{"{.stages["}}).Matches(pipeline); util.ExitCode(err) != util.ExitUsage {
+		t.Fatalf("Expected a usage error for a bad jsonpath, got %v", err)
+	}
+}
+
+func TestApplyPatches(t *testing.T) {
+	var pipeline map[string]interface{}
+	json.Unmarshal([]byte(`{
+  "name": "deploy",
+  "notifications": [{"type": "slack", "address": "#deploys"}],
+  "stages": [
+    {"refId": "1", "type": "wait", "waitTime": 30},
+    {"refId": "2", "type": "manualJudgment", "requisiteStageRefIds": ["1"]}
+  ]
+}`), &pipeline)
+
+	tests := []struct {
+		name     string
+		patch    PipelinePatch
+		expected string
+	}{
+		{
+			"json patch",
+			PipelinePatch{Type: PatchJson, Patch: []byte(`[{"op": "replace", "path": "/stages/0/waitTime", "value": 60}, {"op": "remove", "path": "/notifications"}]`)},
+			`{"name":"deploy","stages":[{"refId":"1","type":"wait","waitTime":60},{"refId":"2","requisiteStageRefIds":["1"],"type":"manualJudgment"}]}`,
+		},
+		{
+			"merge patch",
+			PipelinePatch{Type: PatchMerge, Patch: []byte(`{"notifications": null, "stages": [{"refId": "1", "type": "wait", "waitTime": 60}]}`)},
+			`{"name":"deploy","stages":[{"refId":"1","type":"wait","waitTime":60}]}`,
+		},
+		{
+			"overlay",
+			PipelinePatch{Type: PatchOverlay, Patch: []byte(`{"stages": [{"refId": "1", "waitTime": 60}, {"refId": "2", "$patch": "delete"}, {"refId": "3", "type": "wait"}]}`)},
+			`{"name":"deploy","notifications":[{"address":"#deploys","type":"slack"}],"stages":[{"refId":"1","type":"wait","waitTime":60},{"refId":"3","type":"wait"}]}`,
+		},
+	}
+	for _, test := range tests {
+		patched, err := ApplyPatches(pipeline, []PipelinePatch{test.patch})
+		if err != nil {
+			t.Fatalf("%s: ApplyPatches failed: %v", test.name, err)
+		}
+		actual, _ := json.Marshal(patched)
+		if string(actual) != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, actual)
+		}
+	}
+
+	_, err := ApplyPatches(pipeline, []PipelinePatch{{Type: PatchJson, Patch: []byte(`[{"op": "remove", "path": "/missing"}]`)}})
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CopyOptions controls how CopyPipeline rewrites the pipeline it copies.
+type CopyOptions struct {
+	// NewName names the copy. Defaults to the source pipeline's name.
+	NewName string
+
+	// Accounts maps account names used by the source pipeline to the
+	// accounts the copy should use. Accounts not in the map are kept.
+	Accounts map[string]string
+
+	// DisabledLintRules are skipped when validating the copy.
+	DisabledLintRules []string
+}
+
+// CopyPipeline saves a copy of a pipeline in another application through
+// target, which may reach another Spinnaker, or through c when target is nil.
+// See CopiedPipeline for how the copy is rewritten.
+func (c *Client) CopyPipeline(ctx context.Context, target *Client, fromApplication, name, toApplication string, options CopyOptions) (*SavePipelineResult, error) {
+	if target == nil {
+		target = c
+	}
+	source, err := c.GetPipeline(ctx, fromApplication, name)
+	if err != nil {
+		return nil, err
+	}
+	pipeline, err := CopiedPipeline(source, toApplication, options)
+	if err != nil {
+		return nil, err
+	}
+	return target.SavePipeline(ctx, pipeline, options.DisabledLintRules...)
+}
+
+// CopiedPipeline returns a copy of pipeline for the application, without
+// the keys Front50 manages or its id and index, renamed to options.NewName
+// if set and with its account references mapped by options.Accounts. Keys
+// named "credentials" or ending in "account" or "accounts" are taken to
+// reference accounts. The input is not modified.
+func CopiedPipeline(pipeline map[string]interface{}, application string, options CopyOptions) (map[string]interface{}, error) {
+	copied, err := NormalizePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if copied == nil {
+		return nil, fmt.Errorf("no pipeline to copy")
+	}
+	delete(copied, "id")
+	delete(copied, "index")
+	copied["application"] = application
+	if options.NewName != "" {
+		copied["name"] = options.NewName
+	}
+	if len(options.Accounts) > 0 {
+		mapAccounts(copied, options.Accounts)
+	}
+	return copied, nil
+}
+
+// mapAccounts rewrites the account references in value in place.
+func mapAccounts(value interface{}, accounts map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if isAccountKey(key) {
+				v[key] = mapAccount(child, accounts)
+				continue
+			}
+			mapAccounts(child, accounts)
+		}
+	case []interface{}:
+		for _, child := range v {
+			mapAccounts(child, accounts)
+		}
+	}
+}
+
+// mapAccount maps an account name, or a list of them.
+func mapAccount(value interface{}, accounts map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		if mapped, ok := accounts[v]; ok {
+			return mapped
+		}
+	case []interface{}:
+		for i, account := range v {
+			v[i] = mapAccount(account, accounts)
+		}
+	}
+	return value
+}
+
+func isAccountKey(key string) bool {
+	key = strings.ToLower(key)
+	return key == "credentials" || strings.HasSuffix(key, "account") || strings.HasSuffix(key, "accounts")
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spinnaker/spin/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+// maxExecutionPollAttempts bounds how many times Gate is polled for a started execution.
+const maxExecutionPollAttempts = 8
+
+// DefaultPollInterval is how often WaitForExecution polls unless told otherwise.
+const DefaultPollInterval = 5 * time.Second
+
+// ExecuteOptions configures a manual pipeline trigger.
+type ExecuteOptions struct {
+	// Parameters are the pipeline's parameter values.
+	Parameters map[string]interface{}
+
+	// RunAs is a service account to trigger the pipeline as. The
+	// authenticated user must be permitted to use it.
+	RunAs string
+}
+
+// WaitOptions configures WaitForExecution.
+type WaitOptions struct {
+	// PollInterval is how often the execution is polled, DefaultPollInterval if zero.
+	PollInterval time.Duration
+
+	// OnStatus, if set, is called whenever the execution's status changes.
+	OnStatus func(status string)
+}
+
+// ExecutePipeline triggers the named pipeline and returns the ids of its
+// running executions.
+func (c *Client) ExecutePipeline(ctx context.Context, application, name string, options ExecuteOptions) ([]string, error) {
+	ctx = c.requestContext(ctx)
+	trigger := map[string]interface{}{"type": "manual"}
+	if len(options.Parameters) > 0 {
+		trigger["parameters"] = options.Parameters
+	}
+	if options.RunAs != "" {
+		if err := c.validateRunAs(ctx, options.RunAs); err != nil {
+			return nil, err
+		}
+		trigger["runAsUser"] = options.RunAs
+	}
+
+	_, resp, err := c.gate.PipelineControllerApi.InvokePipelineConfigUsingPOST1(ctx, application, name,
+		map[string]interface{}{"trigger": trigger})
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		return nil, gateclient.NewGateError("Encountered an error executing pipeline", resp, err)
+	}
+
+	executions := make([]interface{}, 0)
+	for attempts := 0; len(executions) == 0 && attempts < maxExecutionPollAttempts; attempts++ {
+		if attempts > 0 {
+			if err = gateclient.Sleep(ctx, gateclient.Backoff(attempts-1)); err != nil {
+				break
+			}
+		}
+		executions, resp, err = c.gate.ExecutionsControllerApi.SearchForPipelineExecutionsByTriggerUsingGET(ctx, application,
+			map[string]interface{}{
+				"pipelineName": name,
+				"statuses":     "RUNNING",
+			})
+		if err != nil {
+			break
+		}
+	}
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, gateclient.NewGateError("Encountered an error querying pipeline execution", resp, err)
+	}
+	if len(executions) == 0 {
+		return nil, fmt.Errorf("Unable to find a running execution of pipeline %s in application %s", name, application)
+	}
+
+	ids := make([]string, 0, len(executions))
+	for _, execution := range executions {
+		if e, ok := execution.(map[string]interface{}); ok {
+			if id, ok := e["id"].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// GetExecution returns a pipeline execution.
+func (c *Client) GetExecution(ctx context.Context, id string) (map[string]interface{}, error) {
+	response, resp, err := c.gate.PipelineControllerApi.GetPipelineUsingGET(c.requestContext(ctx), id)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError(fmt.Sprintf("Encountered an error getting pipeline execution %s", id), resp, err)
+	}
+	execution, _ := response.(map[string]interface{})
+	return execution, nil
+}
+
+// WaitForExecution polls the execution until it completes and returns it.
+// An execution that completes without succeeding is reported as an error.
+func (c *Client) WaitForExecution(ctx context.Context, id string, options WaitOptions) (map[string]interface{}, error) {
+	ctx = c.requestContext(ctx)
+	pollInterval := options.PollInterval
+	if pollInterval == 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	lastStatus := ""
+	for {
+		response, resp, err := c.gate.PipelineControllerApi.GetPipelineUsingGET(ctx, id)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return nil, gateclient.NewGateError("Encountered an error waiting for pipeline execution", resp, err)
+		}
+
+		execution, _ := response.(map[string]interface{})
+		status, _ := execution["status"].(string)
+		if status != lastStatus && options.OnStatus != nil {
+			options.OnStatus(status)
+		}
+		lastStatus = status
+		switch status {
+		case "SUCCEEDED":
+			return execution, nil
+		case "TERMINAL", "CANCELED", "STOPPED", "FAILED_CONTINUE", "SKIPPED":
+			return execution, fmt.Errorf("Pipeline execution %s finished with status %s", id, status)
+		}
+
+		if err := gateclient.Sleep(ctx, pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CancelExecution cancels a running execution.
+func (c *Client) CancelExecution(ctx context.Context, id, reason string) error {
+	_, resp, err := c.gate.PipelineControllerApi.CancelPipelineUsingPUT1(c.requestContext(ctx), id, map[string]interface{}{"reason": reason})
+	// Gate may accept the cancellation with an empty body, which the generated client fails to decode.
+	if resp == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return gateclient.NewGateError("Encountered an error canceling pipeline execution", resp, err)
+	}
+	return nil
+}
+
+// validateRunAs checks that the authenticated user is permitted to trigger
+// pipelines as the given service account.
+func (c *Client) validateRunAs(ctx context.Context, runAs string) error {
+	serviceAccounts, err := c.ListServiceAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	permitted := make([]string, 0, len(serviceAccounts))
+	for _, account := range serviceAccounts {
+		name := ""
+		switch a := account.(type) {
+		case string:
+			name = a
+		case map[string]interface{}:
+			name, _ = a["name"].(string)
+		}
+		if name == runAs {
+			return nil
+		}
+		if name != "" {
+			permitted = append(permitted, name)
+		}
+	}
+	return util.NewAuthError("Not permitted to run as service account '%s'. Permitted service accounts: [%s]\n",
+		runAs, strings.Join(permitted, ", "))
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/spinnaker/spin/util"
+)
+
+// Pipeline patch types.
+const (
+	// PatchJson is an RFC 6902 JSON Patch, a list of operations.
+	PatchJson = "json"
+	// PatchMerge is an RFC 7386 JSON Merge Patch.
+	PatchMerge = "merge"
+	// PatchOverlay is a merge patch that merges lists of objects, such as the
+	// stages, by refId, name or id instead of replacing them. An object with
+	// "$patch": "delete" removes the object it matches.
+	PatchOverlay = "overlay"
+)
+
+// overlayMergeKeys identify the objects of a list in an overlay, in order
+// of preference.
+var overlayMergeKeys = []string{"refId", "name", "id"}
+
+// PipelinePatch is a patch document for PatchPipeline.
+type PipelinePatch struct {
+	// Type is PatchJson, PatchMerge or PatchOverlay.
+	Type string
+
+	// Patch is the patch document, in JSON.
+	Patch []byte
+}
+
+// PatchOptions controls PatchPipeline.
+type PatchOptions struct {
+	// Base is patched in place of the current pipeline, keeping the current
+	// pipeline's id, index, application and name.
+	Base map[string]interface{}
+
+	// DryRun returns the patched pipeline without saving it.
+	DryRun bool
+
+	// DisabledLintRules are skipped when validating the patched pipeline.
+	DisabledLintRules []string
+}
+
+// PatchPipeline applies the patches, in order, to the named pipeline and
+// saves the result over it. The id, application and name can't be patched;
+// use RenamePipeline or CopyPipeline to change them.
+func (c *Client) PatchPipeline(ctx context.Context, application, name string, patches []PipelinePatch, options PatchOptions) (*SavePipelineResult, error) {
+	current, err := c.GetPipeline(ctx, application, name)
+	if err != nil {
+		return nil, err
+	}
+	base := current
+	if options.Base != nil {
+		base = options.Base
+	}
+	base, err = NormalizePipeline(base)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"id", "index", "application", "name"} {
+		if current[key] != nil {
+			base[key] = current[key]
+		}
+	}
+
+	patched, err := ApplyPatches(base, patches)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"id", "application", "name"} {
+		if fmt.Sprintf("%v", patched[key]) != fmt.Sprintf("%v", current[key]) {
+			return nil, util.NewValidationError("patches must not change the pipeline's %s", key)
+		}
+	}
+	if err := ValidatePipeline(patched, options.DisabledLintRules...); err != nil {
+		return nil, err
+	}
+
+	result := &SavePipelineResult{
+		Action:      PipelineUpdated,
+		Application: application,
+		Name:        name,
+		Previous:    current,
+		Pipeline:    patched,
+	}
+	result.Id, _ = current["id"].(string)
+	unchanged, err := pipelinesEqual(patched, current)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		result.Action = PipelineUnchanged
+		return result, nil
+	}
+	if options.DryRun {
+		return result, nil
+	}
+
+	if err := c.UpdatePipeline(ctx, result.Id, patched); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ApplyPatches returns the pipeline with the patches applied in order. The
+// input is not modified.
+func ApplyPatches(pipeline map[string]interface{}, patches []PipelinePatch) (map[string]interface{}, error) {
+	document, err := json.Marshal(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	for i, patch := range patches {
+		document, err = applyPatch(document, patch)
+		if err != nil {
+			return nil, util.NewValidationError("Could not apply patch %d: %v", i+1, err)
+		}
+	}
+	patched := map[string]interface{}{}
+	if err := json.Unmarshal(document, &patched); err != nil {
+		return nil, util.NewValidationError("Patches must leave a pipeline object: %v", err)
+	}
+	return patched, nil
+}
+
+func applyPatch(document []byte, patch PipelinePatch) ([]byte, error) {
+	switch patch.Type {
+	case PatchJson:
+		operations, err := jsonpatch.DecodePatch(patch.Patch)
+		if err != nil {
+			return nil, err
+		}
+		return operations.Apply(document)
+	case PatchMerge:
+		return jsonpatch.MergePatch(document, patch.Patch)
+	case PatchOverlay:
+		var target, overlay interface{}
+		if err := json.Unmarshal(document, &target); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(patch.Patch, &overlay); err != nil {
+			return nil, err
+		}
+		return json.Marshal(applyOverlay(target, overlay))
+	}
+	return nil, fmt.Errorf("unknown patch type %q", patch.Type)
+}
+
+// applyOverlay merges overlay into target. Maps are merged, with null
+// removing a key, and lists of objects sharing a merge key are merged by it.
+// Anything else in the overlay replaces the target.
+func applyOverlay(target, overlay interface{}) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		t, ok := target.(map[string]interface{})
+		if !ok {
+			t = map[string]interface{}{}
+		}
+		for key, value := range o {
+			if value == nil {
+				delete(t, key)
+				continue
+			}
+			t[key] = applyOverlay(t[key], value)
+		}
+		return t
+	case []interface{}:
+		t, ok := target.([]interface{})
+		if !ok {
+			return withoutDirectives(o)
+		}
+		key := overlayMergeKey(t, o)
+		if key == "" {
+			return withoutDirectives(o)
+		}
+		return overlayList(t, o, key)
+	}
+	return overlay
+}
+
+// overlayMergeKey returns the first merge key every object in both lists
+// has, or "" if there is none.
+func overlayMergeKey(lists ...[]interface{}) string {
+	for _, key := range overlayMergeKeys {
+		shared := true
+		for _, list := range lists {
+			for _, item := range list {
+				object, ok := item.(map[string]interface{})
+				if !ok || object[key] == nil {
+					shared = false
+				}
+			}
+		}
+		if shared {
+			return key
+		}
+	}
+	return ""
+}
+
+// overlayList merges the overlay's objects into the target's objects with the
+// same key, appending the rest.
+func overlayList(target, overlay []interface{}, key string) []interface{} {
+	merged := append([]interface{}{}, target...)
+	for _, item := range overlay {
+		object := item.(map[string]interface{})
+		index := -1
+		for i, existing := range merged {
+			if fmt.Sprintf("%v", existing.(map[string]interface{})[key]) == fmt.Sprintf("%v", object[key]) {
+				index = i
+				break
+			}
+		}
+		switch {
+		case object["$patch"] == "delete":
+			if index >= 0 {
+				merged = append(merged[:index], merged[index+1:]...)
+			}
+		case index >= 0:
+			merged[index] = applyOverlay(merged[index], object)
+		default:
+			merged = append(merged, object)
+		}
+	}
+	return merged
+}
+
+// withoutDirectives drops the objects an overlay list deletes.
+func withoutDirectives(list []interface{}) []interface{} {
+	kept := []interface{}{}
+	for _, item := range list {
+		if object, ok := item.(map[string]interface{}); ok && object["$patch"] == "delete" {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
@@ -0,0 +1,375 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/spinnaker/spin/gateclient"
+	"github.com/spinnaker/spin/lint"
+	"github.com/spinnaker/spin/util"
+)
+
+// ListPipelines returns the configs of every pipeline in the application.
+func (c *Client) ListPipelines(ctx context.Context, application string) ([]interface{}, error) {
+	pipelines, resp, err := c.gate.ApplicationControllerApi.GetPipelineConfigsForApplicationUsingGET(c.requestContext(ctx), application)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError(fmt.Sprintf("Encountered an error listing pipelines for application %s",
+			application), resp, err)
+	}
+	return pipelines, nil
+}
+
+// ListAllPipelines returns the configs of every pipeline in every
+// application.
+func (c *Client) ListAllPipelines(ctx context.Context) ([]interface{}, error) {
+	pipelines, resp, err := c.gate.PipelineConfigControllerApi.GetAllPipelineConfigsUsingGET(c.requestContext(ctx))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError("Encountered an error listing pipelines", resp, err)
+	}
+	return pipelines, nil
+}
+
+// GetPipelineById returns the config of the pipeline with the id, such as
+// an execution's pipelineConfigId. Only the application's pipelines are
+// searched if application is set, otherwise those of every application.
+func (c *Client) GetPipelineById(ctx context.Context, application, id string) (map[string]interface{}, error) {
+	var pipelines []interface{}
+	var err error
+	if application != "" {
+		pipelines, err = c.ListPipelines(ctx, application)
+	} else {
+		pipelines, err = c.ListAllPipelines(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pipelines {
+		if pipeline, ok := p.(map[string]interface{}); ok && pipeline["id"] == id {
+			return pipeline, nil
+		}
+	}
+	if application != "" {
+		return nil, util.NewNotFoundError("No pipeline with id %s in application %s", id, application)
+	}
+	return nil, util.NewNotFoundError("No pipeline with id %s", id)
+}
+
+// ValidatePipeline checks the pipeline with the registered lint rules, except
+// those disabled, failing if any report an error. Templated pipelines are
+// marked with their type as a side effect.
+func ValidatePipeline(pipeline map[string]interface{}, disabled ...string) error {
+	problems := []string{}
+	for _, finding := range lint.Errors(lint.Lint(pipeline, disabled...)) {
+		problems = append(problems, finding.String())
+	}
+	if template, exists := pipeline["template"].(map[string]interface{}); exists && len(template) > 0 {
+		pipeline["type"] = "templatedPipeline"
+	}
+
+	if len(problems) > 0 {
+		return util.NewValidationError("Submitted pipeline is invalid:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// Actions reported by SavePipeline.
+const (
+	PipelineCreated   = "created"
+	PipelineUpdated   = "updated"
+	PipelineUnchanged = "unchanged"
+)
+
+// serverManagedPipelineKeys are set by Front50 on every save, so they are
+// ignored when comparing pipelines.
+var serverManagedPipelineKeys = []string{"updateTs", "lastModifiedBy"}
+
+// SavePipelineResult describes what SavePipeline did.
+type SavePipelineResult struct {
+	Action      string `json:"action"`
+	Id          string `json:"id,omitempty"`
+	Application string `json:"application"`
+	Name        string `json:"name"`
+
+	// Previous is the pipeline before the save, or nil if it was created.
+	Previous map[string]interface{} `json:"-"`
+	// Pipeline is the pipeline as submitted to Gate.
+	Pipeline map[string]interface{} `json:"-"`
+}
+
+// FindPipeline returns the named pipeline's config, or nil if the
+// application has no such pipeline.
+func (c *Client) FindPipeline(ctx context.Context, application, name string) (map[string]interface{}, error) {
+	pipeline, resp, err := c.gate.ApplicationControllerApi.GetPipelineConfigUsingGET(c.requestContext(ctx), application, name)
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case err == io.EOF && resp != nil && resp.StatusCode == http.StatusOK:
+		// Older Gates answer an empty 200 for unknown pipelines.
+		return nil, nil
+	case err != nil || resp.StatusCode != http.StatusOK:
+		return nil, gateclient.NewGateError(fmt.Sprintf("Encountered an error getting pipeline in application %s with name %s",
+			application, name), resp, err)
+	}
+	return pipeline, nil
+}
+
+// GetPipeline returns the named pipeline's config, or a not found error if
+// the application has no such pipeline.
+func (c *Client) GetPipeline(ctx context.Context, application, name string) (map[string]interface{}, error) {
+	pipeline, err := c.FindPipeline(ctx, application, name)
+	if err != nil {
+		return nil, err
+	}
+	if pipeline == nil {
+		return nil, util.NewNotFoundError("Pipeline %s does not exist in application %s", name, application)
+	}
+	return pipeline, nil
+}
+
+// RenamePipeline renames a pipeline, failing if the new name is taken.
+func (c *Client) RenamePipeline(ctx context.Context, application, from, to string) error {
+	if _, err := c.GetPipeline(ctx, application, from); err != nil {
+		return err
+	}
+	existing, err := c.FindPipeline(ctx, application, to)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return util.NewConflictError("Pipeline %s already exists in application %s", to, application)
+	}
+
+	resp, err := c.gate.PipelineControllerApi.RenamePipelineUsingPOST(c.requestContext(ctx), map[string]interface{}{
+		"application": application,
+		"from":        from,
+		"to":          to,
+	})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return gateclient.NewGateError(fmt.Sprintf("Encountered an error renaming pipeline %s to %s", from, to), resp, err)
+	}
+	return nil
+}
+
+// SetPipelineDisabled disables or enables the named pipeline, leaving the
+// rest of its config as it is.
+func (c *Client) SetPipelineDisabled(ctx context.Context, application, name string, disabled bool) (*SavePipelineResult, error) {
+	pipeline, err := c.GetPipeline(ctx, application, name)
+	if err != nil {
+		return nil, err
+	}
+	result := &SavePipelineResult{
+		Action:      PipelineUnchanged,
+		Application: application,
+		Name:        name,
+		Previous:    pipeline,
+		Pipeline:    pipeline,
+	}
+	result.Id, _ = pipeline["id"].(string)
+	if current, _ := pipeline["disabled"].(bool); current == disabled {
+		return result, nil
+	}
+
+	updated := map[string]interface{}{}
+	for k, v := range pipeline {
+		updated[k] = v
+	}
+	updated["disabled"] = disabled
+	if err := c.UpdatePipeline(ctx, result.Id, updated); err != nil {
+		return nil, err
+	}
+	result.Action = PipelineUpdated
+	result.Pipeline = updated
+	return result, nil
+}
+
+// NormalizePipeline returns a copy of the pipeline without the keys Front50
+// manages, suitable for comparing and diffing.
+func NormalizePipeline(pipeline map[string]interface{}) (map[string]interface{}, error) {
+	if pipeline == nil {
+		return nil, nil
+	}
+	// Round trip through JSON so numbers compare the same as Gate's.
+	b, err := json.Marshal(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	normalized := map[string]interface{}{}
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return nil, err
+	}
+	for _, key := range serverManagedPipelineKeys {
+		delete(normalized, key)
+	}
+	return normalized, nil
+}
+
+// ComparablePipelines prepares a local pipeline and the deployed one for
+// comparison. Keys Front50 manages are dropped from both, as are the index
+// and any ids the deployed pipeline has where the local one has none, since
+// Front50 and Deck generate them. The inputs are not modified.
+func ComparablePipelines(local, deployed map[string]interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	normalizedLocal, err := NormalizePipeline(local)
+	if err != nil {
+		return nil, nil, err
+	}
+	normalizedDeployed, err := NormalizePipeline(deployed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if normalizedLocal != nil && normalizedDeployed != nil {
+		if _, exists := normalizedLocal["index"]; !exists {
+			delete(normalizedDeployed, "index")
+		}
+		dropGeneratedIds(normalizedLocal, normalizedDeployed)
+	}
+	return normalizedLocal, normalizedDeployed, nil
+}
+
+// dropGeneratedIds removes "id" keys from deployed wherever the matching
+// object in local has none.
+func dropGeneratedIds(local, deployed interface{}) {
+	switch localValue := local.(type) {
+	case map[string]interface{}:
+		deployedValue, ok := deployed.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if _, exists := localValue["id"]; !exists {
+			delete(deployedValue, "id")
+		}
+		for k, v := range localValue {
+			dropGeneratedIds(v, deployedValue[k])
+		}
+	case []interface{}:
+		deployedValue, ok := deployed.([]interface{})
+		if !ok {
+			return
+		}
+		for i := 0; i < len(localValue) && i < len(deployedValue); i++ {
+			dropGeneratedIds(localValue[i], deployedValue[i])
+		}
+	}
+}
+
+// SavePipeline validates the pipeline, skipping the disabled lint rules, and
+// creates it, or updates the existing pipeline with the same name. The
+// existing pipeline's id and index are reused when the pipeline does not set
+// them, and nothing is saved if the pipeline is unchanged.
+func (c *Client) SavePipeline(ctx context.Context, pipeline map[string]interface{}, disabledLintRules ...string) (*SavePipelineResult, error) {
+	if err := ValidatePipeline(pipeline, disabledLintRules...); err != nil {
+		return nil, err
+	}
+	application := fmt.Sprintf("%v", pipeline["application"])
+	name := fmt.Sprintf("%v", pipeline["name"])
+	existing, err := c.FindPipeline(ctx, application, name)
+	if err != nil {
+		return nil, err
+	}
+	result, err := planSave(pipeline, existing)
+	if err != nil {
+		return nil, err
+	}
+	if result.Action == PipelineUnchanged {
+		return result, nil
+	}
+	if err := c.postPipeline(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// planSave decides whether saving the pipeline would create it, update the
+// existing pipeline of the same name or leave it unchanged. The existing
+// pipeline's id and index are copied to the pipeline when it has none.
+func planSave(pipeline, existing map[string]interface{}) (*SavePipelineResult, error) {
+	result := &SavePipelineResult{
+		Action:      PipelineCreated,
+		Application: fmt.Sprintf("%v", pipeline["application"]),
+		Name:        fmt.Sprintf("%v", pipeline["name"]),
+		Pipeline:    pipeline,
+	}
+	result.Id, _ = pipeline["id"].(string)
+	if existing == nil {
+		return result, nil
+	}
+
+	existingId, _ := existing["id"].(string)
+	if result.Id != "" && existingId != "" && result.Id != existingId {
+		return nil, util.NewValidationError("Pipeline %s in application %s already exists with id %s, but the submitted pipeline has id %s",
+			result.Name, result.Application, existingId, result.Id)
+	}
+	for _, key := range []string{"id", "index"} {
+		if _, exists := pipeline[key]; !exists && existing[key] != nil {
+			pipeline[key] = existing[key]
+		}
+	}
+	result.Id = existingId
+	result.Action = PipelineUpdated
+	result.Previous = existing
+
+	unchanged, err := pipelinesEqual(pipeline, existing)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		result.Action = PipelineUnchanged
+	}
+	return result, nil
+}
+
+// postPipeline saves the planned pipeline, looking up the id Front50
+// assigns to new pipelines.
+func (c *Client) postPipeline(ctx context.Context, result *SavePipelineResult) error {
+	resp, err := c.gate.PipelineControllerApi.SavePipelineUsingPOST(c.requestContext(ctx), result.Pipeline)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return gateclient.NewGateError(fmt.Sprintf("Encountered an error saving pipeline %s in application %s",
+			result.Name, result.Application), resp, err)
+	}
+
+	if result.Id == "" {
+		saved, err := c.FindPipeline(ctx, result.Application, result.Name)
+		if err != nil {
+			return err
+		}
+		result.Id, _ = saved["id"].(string)
+	}
+	return nil
+}
+
+// pipelinesEqual reports whether saving the local pipeline would leave the
+// deployed one unchanged, comparing them as ComparablePipelines prepares them.
+func pipelinesEqual(local, deployed map[string]interface{}) (bool, error) {
+	comparableLocal, comparableDeployed, err := ComparablePipelines(local, deployed)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(comparableLocal, comparableDeployed), nil
+}
+
+// DeletePipeline deletes the named pipeline.
+func (c *Client) DeletePipeline(ctx context.Context, application, name string) error {
+	resp, err := c.gate.PipelineControllerApi.DeletePipelineUsingDELETE(c.requestContext(ctx), application, name)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return gateclient.NewGateError("Encountered an error deleting pipeline", resp, err)
+	}
+	return nil
+}
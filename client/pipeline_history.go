@@ -0,0 +1,130 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/spinnaker/spin/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+// DefaultHistoryLimit is the number of revisions Front50 returns by default.
+const DefaultHistoryLimit = 20
+
+// GetPipelineHistory returns up to limit revisions of the named pipeline,
+// newest first, so revision 1 is the current config.
+func (c *Client) GetPipelineHistory(ctx context.Context, application, name string, limit int) ([]map[string]interface{}, error) {
+	current, err := c.GetPipeline(ctx, application, name)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := current["id"].(string)
+	if id == "" {
+		return nil, fmt.Errorf("Pipeline %s in application %s has no id", name, application)
+	}
+
+	history, resp, err := c.gate.PipelineConfigControllerApi.GetPipelineConfigHistoryUsingGET(c.requestContext(ctx), id,
+		map[string]interface{}{"limit": int32(limit)})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError(fmt.Sprintf("Encountered an error getting the history of pipeline %s in application %s",
+			name, application), resp, err)
+	}
+
+	revisions := []map[string]interface{}{}
+	for _, h := range history {
+		if revision, ok := h.(map[string]interface{}); ok {
+			revisions = append(revisions, revision)
+		}
+	}
+	return revisions, nil
+}
+
+// GetPipelineRevision returns a revision of the named pipeline, where
+// revision 1 is the current config, 2 the one before it, and so on.
+func (c *Client) GetPipelineRevision(ctx context.Context, application, name string, revision int) (map[string]interface{}, error) {
+	if revision < 1 {
+		return nil, util.NewUsageError("revision must be 1 or greater, got %d", revision)
+	}
+	history, err := c.GetPipelineHistory(ctx, application, name, revision)
+	if err != nil {
+		return nil, err
+	}
+	if revision > len(history) {
+		return nil, util.NewNotFoundError("Pipeline %s in application %s has %d revisions, revision %d does not exist",
+			name, application, len(history), revision)
+	}
+	return history[revision-1], nil
+}
+
+// UpdatePipeline replaces the pipeline with the given id.
+func (c *Client) UpdatePipeline(ctx context.Context, id string, pipeline map[string]interface{}) error {
+	_, resp, err := c.gate.PipelineControllerApi.UpdatePipelineUsingPUT(c.requestContext(ctx), id, pipeline)
+	if err == io.EOF && resp != nil && resp.StatusCode == http.StatusOK {
+		// Some Gates answer with an empty body.
+		err = nil
+	}
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return gateclient.NewGateError(fmt.Sprintf("Encountered an error updating pipeline %s", id), resp, err)
+	}
+	return nil
+}
+
+// RollbackPipeline restores a revision of the named pipeline, as numbered
+// by GetPipelineRevision. The current id and index are kept.
+func (c *Client) RollbackPipeline(ctx context.Context, application, name string, revision int) (*SavePipelineResult, error) {
+	current, err := c.GetPipeline(ctx, application, name)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := c.GetPipelineRevision(ctx, application, name, revision)
+	if err != nil {
+		return nil, err
+	}
+	restored, err = NormalizePipeline(restored)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"id", "index"} {
+		if current[key] != nil {
+			restored[key] = current[key]
+		}
+	}
+
+	result := &SavePipelineResult{
+		Action:      PipelineUpdated,
+		Application: application,
+		Name:        name,
+		Previous:    current,
+		Pipeline:    restored,
+	}
+	result.Id, _ = current["id"].(string)
+	unchanged, err := pipelinesEqual(restored, current)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged {
+		result.Action = PipelineUnchanged
+		return result, nil
+	}
+
+	if err := c.UpdatePipeline(ctx, result.Id, restored); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spinnaker/spin/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+// GetPipelineTemplate returns the pipeline template with the given id.
+func (c *Client) GetPipelineTemplate(ctx context.Context, id string) (map[string]interface{}, error) {
+	template, resp, err := c.gate.V2PipelineTemplatesControllerApi.GetUsingGET1(c.requestContext(ctx), id)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError(fmt.Sprintf("Encountered an error getting pipeline template with id %s", id), resp, err)
+	}
+	return template, nil
+}
+
+// ListPipelineTemplates returns the pipeline templates in the given
+// scopes, or every template if scopes is empty.
+func (c *Client) ListPipelineTemplates(ctx context.Context, scopes []string) ([]interface{}, error) {
+	query := map[string]interface{}{}
+	if len(scopes) > 0 {
+		query["scopes"] = scopes
+	}
+	templates, resp, err := c.gate.V2PipelineTemplatesControllerApi.ListUsingGET1(c.requestContext(ctx), query)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError(fmt.Sprintf("Encountered an error listing pipeline templates for scopes %v", scopes), resp, err)
+	}
+	return templates, nil
+}
+
+// ValidatePipelineTemplate checks that the template has the keys Gate requires.
+func ValidatePipelineTemplate(template map[string]interface{}) error {
+	problems := []string{}
+	if _, exists := template["id"].(string); !exists {
+		problems = append(problems, "Required pipeline template key 'id' missing")
+	}
+	if _, exists := template["schema"]; !exists {
+		problems = append(problems, "Required pipeline template key 'schema' missing")
+	}
+
+	if len(problems) > 0 {
+		return util.NewValidationError("Submitted pipeline template is invalid:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// SavePipelineTemplate validates the template and creates it, or updates
+// it if a template with the same id exists.
+func (c *Client) SavePipelineTemplate(ctx context.Context, template map[string]interface{}) error {
+	if err := ValidatePipelineTemplate(template); err != nil {
+		return err
+	}
+	ctx = c.requestContext(ctx)
+	id := template["id"].(string)
+
+	_, resp, queryErr := c.gate.V2PipelineTemplatesControllerApi.GetUsingGET1(ctx, id)
+
+	var saveResp *http.Response
+	var saveErr error
+	if resp != nil && resp.StatusCode == http.StatusOK {
+		saveResp, saveErr = c.gate.V2PipelineTemplatesControllerApi.UpdateUsingPOST1(ctx, id, template, nil)
+	} else if resp != nil && resp.StatusCode == http.StatusNotFound {
+		saveResp, saveErr = c.gate.V2PipelineTemplatesControllerApi.CreateUsingPOST1(ctx, template)
+	} else {
+		return gateclient.NewGateError(fmt.Sprintf("Encountered an error querying pipeline template with id %s", id), resp, queryErr)
+	}
+
+	if saveErr != nil || saveResp.StatusCode != http.StatusAccepted {
+		return gateclient.NewGateError(fmt.Sprintf("Encountered an error saving pipeline template %s", id), saveResp, saveErr)
+	}
+	return nil
+}
+
+// DeletePipelineTemplate deletes the pipeline template with the given id.
+func (c *Client) DeletePipelineTemplate(ctx context.Context, id string) error {
+	_, resp, err := c.gate.V2PipelineTemplatesControllerApi.DeleteUsingDELETE1(c.requestContext(ctx), id, nil)
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		return gateclient.NewGateError("Encountered an error deleting pipeline template", resp, err)
+	}
+	return nil
+}
+
+// PlanPipelineTemplate renders the templated pipeline config without
+// saving it, returning the resulting pipeline.
+func (c *Client) PlanPipelineTemplate(ctx context.Context, config map[string]interface{}) (map[string]interface{}, error) {
+	if _, exists := config["schema"]; !exists {
+		return nil, util.NewValidationError("Required pipeline key 'schema' missing for templated pipeline config...\n")
+	}
+
+	// Planning doesn't modify anything server-side, so transient failures can be retried.
+	plan, resp, err := c.gate.V2PipelineTemplatesControllerApi.PlanUsingPOST(gateclient.RetrySafe(c.requestContext(ctx)), config)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, gateclient.NewGateError("Encountered an error planning pipeline template config", resp, err)
+	}
+	return plan, nil
+}
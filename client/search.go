@@ -0,0 +1,265 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spinnaker/spin/util"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// PipelineQuery selects pipelines for SearchPipelines. A pipeline matches
+// when it satisfies every field that is set, and satisfies a list field when
+// any of its entries match.
+type PipelineQuery struct {
+	// Application limits the search to one application's pipelines.
+	Application string
+
+	// StageTypes matches pipelines with a stage of one of the types.
+	StageTypes []string
+
+	// Accounts matches pipelines referencing one of the accounts, in keys
+	// named "credentials" or ending in "account" or "accounts".
+	Accounts []string
+
+	// TriggerTypes matches pipelines with a trigger of one of the types.
+	TriggerTypes []string
+
+	// Contains matches pipelines with a key or value containing one of the
+	// strings, ignoring case.
+	Contains []string
+
+	// JsonPaths matches pipelines for which one of the jsonpath
+	// expressions, such as '{.stages[?(@.type=="webhook")]}', finds a value.
+	JsonPaths []string
+}
+
+// PipelineMatch identifies a pipeline found by SearchPipelines.
+type PipelineMatch struct {
+	Application string `json:"application"`
+	Name        string `json:"name"`
+	Id          string `json:"id"`
+}
+
+// SearchPipelines returns the pipelines matching the query, ordered by
+// application and name.
+func (c *Client) SearchPipelines(ctx context.Context, query PipelineQuery) ([]PipelineMatch, error) {
+	matcher, err := query.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelines []interface{}
+	if query.Application != "" {
+		pipelines, err = c.ListPipelines(ctx, query.Application)
+	} else {
+		pipelines, err = c.ListAllPipelines(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []PipelineMatch{}
+	for _, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok || !matcher(pipeline) {
+			continue
+		}
+		application, _ := pipeline["application"].(string)
+		name, _ := pipeline["name"].(string)
+		id, _ := pipeline["id"].(string)
+		matches = append(matches, PipelineMatch{
+			Application: application,
+			Name:        name,
+			Id:          id,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Application != matches[j].Application {
+			return matches[i].Application < matches[j].Application
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches, nil
+}
+
+// Matches reports whether the pipeline satisfies the query, ignoring its
+// Application.
+func (q PipelineQuery) Matches(pipeline map[string]interface{}) (bool, error) {
+	matcher, err := q.matcher()
+	if err != nil {
+		return false, err
+	}
+	return matcher(pipeline), nil
+}
+
+// matcher parses the query's jsonpath expressions once and returns a
+// predicate for pipelines.
+func (q PipelineQuery) matcher() (func(map[string]interface{}) bool, error) {
+	paths := []*jsonpath.JSONPath{}
+	for _, expression := range q.JsonPaths {
+		path := jsonpath.New("search").AllowMissingKeys(true)
+		if err := path.Parse(expression); err != nil {
+			return nil, util.NewUsageError("invalid jsonpath %s: %v", expression, err)
+		}
+		paths = append(paths, path)
+	}
+	contains := []string{}
+	for _, s := range q.Contains {
+		contains = append(contains, strings.ToLower(s))
+	}
+
+	return func(pipeline map[string]interface{}) bool {
+		if len(q.StageTypes) > 0 && !anyIn(listTypes(pipeline["stages"]), q.StageTypes) {
+			return false
+		}
+		if len(q.TriggerTypes) > 0 && !anyIn(listTypes(pipeline["triggers"]), q.TriggerTypes) {
+			return false
+		}
+		if len(q.Accounts) > 0 && !anyIn(referencedAccounts(pipeline), q.Accounts) {
+			return false
+		}
+		if len(contains) > 0 && !containsAny(pipeline, contains) {
+			return false
+		}
+		if len(paths) > 0 && !anyPathFound(pipeline, paths) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// listTypes returns the types of the objects in a list such as the stages.
+func listTypes(list interface{}) []string {
+	types := []string{}
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			if t, ok := object["type"].(string); ok {
+				types = append(types, t)
+			}
+		}
+	}
+	return types
+}
+
+// referencedAccounts returns the accounts referenced anywhere in value.
+func referencedAccounts(value interface{}) []string {
+	accounts := []string{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if !isAccountKey(key) {
+				accounts = append(accounts, referencedAccounts(child)...)
+				continue
+			}
+			switch account := child.(type) {
+			case string:
+				accounts = append(accounts, account)
+			case []interface{}:
+				for _, a := range account {
+					if s, ok := a.(string); ok {
+						accounts = append(accounts, s)
+					}
+				}
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			accounts = append(accounts, referencedAccounts(child)...)
+		}
+	}
+	return accounts
+}
+
+func anyIn(values, wanted []string) bool {
+	for _, value := range values {
+		for _, w := range wanted {
+			if value == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsAny reports whether a key or scalar value in value contains one of
+// the lower case strings.
+func containsAny(value interface{}, lowerStrings []string) bool {
+	matches := func(s string) bool {
+		s = strings.ToLower(s)
+		for _, l := range lowerStrings {
+			if strings.Contains(s, l) {
+				return true
+			}
+		}
+		return false
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if matches(key) || containsAny(child, lowerStrings) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if containsAny(child, lowerStrings) {
+				return true
+			}
+		}
+	case nil:
+		return false
+	default:
+		return matches(fmt.Sprintf("%v", v))
+	}
+	return false
+}
+
+// anyPathFound reports whether one of the paths finds a value other than
+// null, false or an empty string, list or object.
+func anyPathFound(pipeline map[string]interface{}, paths []*jsonpath.JSONPath) bool {
+	for _, path := range paths {
+		results, err := path.FindResults(pipeline)
+		if err != nil {
+			continue
+		}
+		for _, result := range results {
+			for _, value := range result {
+				if value.IsValid() && !isEmptyValue(value.Interface()) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isEmptyValue(value interface{}) bool {
+	if value == nil || value == false || value == "" {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return false
+}
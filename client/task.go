@@ -0,0 +1,107 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spinnaker/spin/gateclient"
+)
+
+// maxTaskPollAttempts bounds how many times a task is polled, about a minute with backoff.
+const maxTaskPollAttempts = 8
+
+// SubmitTask starts an Orca task, such as {"job": [...], "application": ...,
+// "description": ...}, and returns its id.
+func (c *Client) SubmitTask(ctx context.Context, task map[string]interface{}) (string, error) {
+	return c.submitTask(ctx, task, fmt.Sprintf("Encountered an error submitting task %v", task["description"]))
+}
+
+// WaitForTask polls the task until it completes and returns it. A task that
+// completes without succeeding is reported as a *TaskError.
+func (c *Client) WaitForTask(ctx context.Context, id string) (map[string]interface{}, error) {
+	return c.waitForTask(ctx, id, fmt.Sprintf("Encountered an error running task %s", id))
+}
+
+// runTask submits the task and waits for it, describing failures with message.
+func (c *Client) runTask(ctx context.Context, task map[string]interface{}, message string) error {
+	id, err := c.submitTask(ctx, task, message)
+	if err != nil {
+		return err
+	}
+	_, err = c.waitForTask(ctx, id, message)
+	return err
+}
+
+func (c *Client) submitTask(ctx context.Context, task map[string]interface{}, message string) (string, error) {
+	ref, resp, err := c.gate.TaskControllerApi.TaskUsingPOST1(c.requestContext(ctx), task)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", gateclient.NewGateError(message, resp, err)
+	}
+	refPath, _ := ref["ref"].(string)
+	toks := strings.Split(refPath, "/")
+	return toks[len(toks)-1], nil
+}
+
+func (c *Client) waitForTask(ctx context.Context, id string, message string) (map[string]interface{}, error) {
+	ctx = c.requestContext(ctx)
+	task, resp, err := c.gate.TaskControllerApi.GetTaskUsingGET1(ctx, id)
+	for attempts := 0; err == nil && !taskCompleted(task) && attempts < maxTaskPollAttempts; attempts++ {
+		if err = gateclient.Sleep(ctx, gateclient.Backoff(attempts)); err != nil {
+			break
+		}
+		task, resp, err = c.gate.TaskControllerApi.GetTaskUsingGET1(ctx, id)
+	}
+
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, gateclient.NewGateError(message, resp, err)
+	}
+	if !taskSucceeded(task) {
+		return task, gateclient.NewTaskError(message, task)
+	}
+	return task, nil
+}
+
+func taskCompleted(task map[string]interface{}) bool {
+	taskStatus, exists := task["status"]
+	if !exists {
+		return false
+	}
+
+	COMPLETED := [...]string{"SUCCEEDED", "STOPPED", "SKIPPED", "TERMINAL", "FAILED_CONTINUE"}
+	for _, status := range COMPLETED {
+		if taskStatus == status {
+			return true
+		}
+	}
+	return false
+}
+
+func taskSucceeded(task map[string]interface{}) bool {
+	taskStatus, exists := task["status"]
+	if !exists {
+		return false
+	}
+
+	SUCCESSFUL := [...]string{"SUCCEEDED", "STOPPED", "SKIPPED"}
+	for _, status := range SUCCESSFUL {
+		if taskStatus == status {
+			return true
+		}
+	}
+	return false
+}
@@ -15,11 +15,8 @@
 package application
 
 import (
-	"errors"
-	"fmt"
-	"net/http"
-
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
 )
@@ -47,38 +44,19 @@ func NewDeleteCmd(appOptions applicationOptions) *cobra.Command {
 }
 
 func deleteApplication(cmd *cobra.Command, args []string) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
-
+	spinClient := client.NewFromGateClient(gateClient)
 	if len(args) == 0 || args[0] == "" {
-		return errors.New("application name required")
+		return util.NewUsageError("application name required")
 	}
-	applicationName := args[0]
 
-	appSpec := map[string]interface{}{
-		"type": "deleteApplication",
-		"application": map[string]interface{}{
-			"name": applicationName,
-		},
-	}
-
-	createAppTask := map[string]interface{}{
-		"job":         []interface{}{appSpec},
-		"application": applicationName,
-		"description": fmt.Sprintf("Delete Application: %s", applicationName),
-	}
-	_, resp, err := gateClient.TaskControllerApi.TaskUsingPOST1(gateClient.Context, createAppTask)
-
-	if err != nil {
+	if err := spinClient.DeleteApplication(cmd.Context(), args[0]); err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error deleting application, status code: %d\n", resp.StatusCode)
-	}
-
-	util.UI.Output(util.Colorize().Color(fmt.Sprintf("[reset][bold][green]Application deleted")))
+	gateClient.UI.Success("Application deleted")
 	return nil
 }
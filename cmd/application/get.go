@@ -15,13 +15,10 @@
 package application
 
 import (
-	"errors"
-	"fmt"
-	"net/http"
-
 	"github.com/spinnaker/spin/util"
 
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 )
 
@@ -50,27 +47,19 @@ func NewGetCmd(appOptions applicationOptions) *cobra.Command {
 }
 
 func getApplication(cmd *cobra.Command, args []string) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 	if len(args) == 0 || args[0] == "" {
-		return errors.New("application name required")
-	}
-	applicationName := args[0]
-	app, resp, err := gateClient.ApplicationControllerApi.GetApplicationUsingGET(gateClient.Context, applicationName, map[string]interface{}{})
-	if resp != nil {
-		if resp.StatusCode == http.StatusNotFound {
-			return fmt.Errorf("Application '%s' not found\n", applicationName)
-		} else if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("Encountered an error getting application, status code: %d\n", resp.StatusCode)
-		}
+		return util.NewUsageError("application name required")
 	}
 
+	app, err := spinClient.GetApplication(cmd.Context(), args[0])
 	if err != nil {
 		return err
 	}
 
-	util.UI.JsonOutput(app, util.UI.OutputFormat)
-	return nil
+	return gateClient.UI.JsonOutput(app)
 }
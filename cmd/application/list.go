@@ -15,12 +15,9 @@
 package application
 
 import (
-	"fmt"
-	"net/http"
-
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
-	"github.com/spinnaker/spin/util"
 )
 
 type ListOptions struct {
@@ -47,19 +44,15 @@ func NewListCmd(appOptions applicationOptions) *cobra.Command {
 }
 
 func listApplication(cmd *cobra.Command, args []string) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
-	appList, resp, err := gateClient.ApplicationControllerApi.GetAllApplicationsUsingGET(gateClient.Context, map[string]interface{}{})
+	spinClient := client.NewFromGateClient(gateClient)
+	appList, err := spinClient.ListApplications(cmd.Context())
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error saving application, status code: %d\n", resp.StatusCode)
-	}
-
-	util.UI.JsonOutput(appList, util.UI.OutputFormat)
-	return nil
+	return gateClient.UI.JsonOutput(appList)
 }
@@ -15,12 +15,10 @@
 package application
 
 import (
-	"errors"
 	"fmt"
-	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
 )
@@ -33,6 +31,7 @@ type SaveOptions struct {
 	cloudProviders  *[]string
 }
 
+
 var (
 	saveApplicationShort   = "Save the provided application"
 	saveApplicationLong    = "Save the specified application"
@@ -60,37 +59,37 @@ func NewSaveCmd(appOptions applicationOptions) *cobra.Command {
 
 func saveApplication(cmd *cobra.Command, options SaveOptions) error {
 	// TODO(jacobkiefer): Should we check for an existing application of the same name?
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
 	initialApp, err := util.ParseJsonFromFileOrStdin(options.applicationFile)
 	if err != nil {
-		util.UI.Error(fmt.Sprintf("%s\n", err))
+		gateClient.UI.Error(fmt.Sprintf("%s\n", err))
 	}
 
 	var app map[string]interface{}
 	if initialApp != nil && len(initialApp) > 0 {
 		app = initialApp
 		if len(*options.cloudProviders) != 0 {
-			util.UI.Warn("Overriding application cloud providers with explicit flag values.\n")
+			gateClient.UI.Warn("Overriding application cloud providers with explicit flag values.\n")
 			app["cloudProviders"] = options.cloudProviders
 		}
 		if options.applicationName != "" {
-			util.UI.Warn("Overriding application name with explicit flag values.\n")
+			gateClient.UI.Warn("Overriding application name with explicit flag values.\n")
 			app["name"] = options.applicationName
 		}
 		if options.ownerEmail != "" {
-			util.UI.Warn("Overriding application owner email with explicit flag values.\n")
+			gateClient.UI.Warn("Overriding application owner email with explicit flag values.\n")
 			app["email"] = options.ownerEmail
 		}
 		// TODO(jacobkiefer): Add validation for valid cloudProviders and well-formed emails.
 		if !(app["cloudProviders"] != nil && app["name"] != "" && app["email"] != "") {
-			return errors.New("Required application parameter missing, exiting...")
+			return util.NewValidationError("Required application parameter missing, exiting...")
 		}
 	} else {
 		if options.applicationName == "" || options.ownerEmail == "" || len(*options.cloudProviders) == 0 {
-			return errors.New("Required application parameter missing, exiting...")
+			return util.NewUsageError("Required application parameter missing, exiting...")
 		}
 		app = map[string]interface{}{
 			"cloudProviders": options.cloudProviders,
@@ -100,72 +99,10 @@ func saveApplication(cmd *cobra.Command, options SaveOptions) error {
 		}
 	}
 
-	createAppTask := map[string]interface{}{
-		"job":         []interface{}{map[string]interface{}{"type": "createApplication", "application": app}},
-		"application": app["name"],
-		"description": fmt.Sprintf("Create Application: %s", app["name"]),
-	}
-
-	ref, _, err := gateClient.TaskControllerApi.TaskUsingPOST1(gateClient.Context, createAppTask)
-	if err != nil {
+	if err := client.NewFromGateClient(gateClient).SaveApplication(cmd.Context(), app); err != nil {
 		return err
 	}
 
-	toks := strings.Split(ref["ref"].(string), "/")
-	id := toks[len(toks)-1]
-
-	task, resp, err := gateClient.TaskControllerApi.GetTaskUsingGET1(gateClient.Context, id)
-	attempts := 0
-	for (task == nil || !taskCompleted(task)) && attempts < 5 {
-		toks := strings.Split(ref["ref"].(string), "/")
-		id := toks[len(toks)-1]
-
-		task, resp, err = gateClient.TaskControllerApi.GetTaskUsingGET1(gateClient.Context, id)
-		attempts += 1
-		time.Sleep(time.Duration(attempts*attempts) * time.Second)
-	}
-
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return fmt.Errorf("Encountered an error saving application, status code: %d\n", resp.StatusCode)
-	}
-	if !taskSucceeded(task) {
-		return fmt.Errorf("Encountered an error saving application, task output was: %v\n", task)
-	}
-
-	util.UI.Info(util.Colorize().Color(fmt.Sprintf("[reset][bold][green]Application save succeeded")))
+	gateClient.UI.Success("Application save succeeded")
 	return nil
 }
-
-// TODO(jacobkiefer): Consider generalizing if we need these functions elsewhere.
-func taskCompleted(task map[string]interface{}) bool {
-	taskStatus, exists := task["status"]
-	if !exists {
-		return false
-	}
-
-	COMPLETED := [...]string{"SUCCEEDED", "STOPPED", "SKIPPED", "TERMINAL", "FAILED_CONTINUE"}
-	for _, status := range COMPLETED {
-		if taskStatus == status {
-			return true
-		}
-	}
-	return false
-}
-
-func taskSucceeded(task map[string]interface{}) bool {
-	taskStatus, exists := task["status"]
-	if !exists {
-		return false
-	}
-
-	SUCCESSFUL := [...]string{"SUCCEEDED", "STOPPED", "SKIPPED"}
-	for _, status := range SUCCESSFUL {
-		if taskStatus == status {
-			return true
-		}
-	}
-	return false
-}
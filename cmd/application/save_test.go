@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -75,6 +76,32 @@ func TestApplicationSave_fail(t *testing.T) {
 	}
 }
 
+func TestApplicationSave_taskFailed(t *testing.T) {
+	ts := testGateApplicationSaveTerminal()
+	defer ts.Close()
+	currentCmd := NewSaveCmd(applicationOptions{})
+	rootCmd := getRootCmdForTest()
+	appCmd := NewApplicationCmd(os.Stdout)
+	appCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(appCmd)
+
+	args := []string{
+		"application", "save",
+		"--gate-endpoint=" + ts.URL,
+		"--application-name", NAME,
+		"--owner-email", EMAIL,
+		"--cloud-providers", "gce,kubernetes",
+	}
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatalf("Expected failure but command succeeded")
+	}
+	if !strings.Contains(err.Error(), "Application 'app' already exists") {
+		t.Fatalf("Expected the task failure in the error, got: %s", err)
+	}
+}
+
 func TestApplicationSave_flags(t *testing.T) {
 	ts := testGateApplicationSaveSuccess()
 	defer ts.Close()
@@ -258,6 +285,37 @@ func testGateApplicationSaveSuccess() *httptest.Server {
 	return httptest.NewServer(mux)
 }
 
+// testGateApplicationSaveTerminal responds to the save with a task that
+// Orca failed with a validation error.
+func testGateApplicationSaveTerminal() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/tasks", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"ref": "/tasks/id"}`)
+	}))
+	mux.Handle("/tasks/id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, strings.TrimSpace(terminalTaskJson))
+	}))
+	return httptest.NewServer(mux)
+}
+
+const terminalTaskJson = `
+{
+  "id": "id",
+  "status": "TERMINAL",
+  "variables": [
+    {
+      "key": "exception",
+      "value": {
+        "details": {
+          "error": "Unexpected Task Failure",
+          "errors": ["Application 'app' already exists"]
+        }
+      }
+    }
+  ]
+}
+`
+
 func tempAppFile(appContent string) *os.File {
 	tempFile, _ := ioutil.TempFile("" /* /tmp dir. */, "app-spec")
 	bytes, err := tempFile.Write([]byte(appContent))
@@ -0,0 +1,44 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+type authOptions struct {
+}
+
+var (
+	authShort   = "Inspect the authenticated user's identity and permissions"
+	authLong    = "Inspect the authenticated user's identity and permissions"
+	authExample = ""
+)
+
+func NewAuthCmd(out io.Writer) *cobra.Command {
+	options := authOptions{}
+	cmd := &cobra.Command{
+		Use:     "auth",
+		Short:   authShort,
+		Long:    authLong,
+		Example: authExample,
+	}
+
+	// create subcommands
+	cmd.AddCommand(NewServiceAccountsCmd(options))
+	return cmd
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+)
+
+type ServiceAccountsOptions struct {
+	*authOptions
+}
+
+var (
+	serviceAccountsShort   = "List the service accounts the current user may run pipelines as"
+	serviceAccountsLong    = "List the service accounts the current user may run pipelines as. Any of these may be passed to --run-as."
+	serviceAccountsExample = "usage: spin auth service-accounts [options]"
+)
+
+func NewServiceAccountsCmd(authOptions authOptions) *cobra.Command {
+	options := ServiceAccountsOptions{
+		authOptions: &authOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "service-accounts",
+		Aliases: []string{"sa"},
+		Short:   serviceAccountsShort,
+		Long:    serviceAccountsLong,
+		Example: serviceAccountsExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listServiceAccounts(cmd, options)
+		},
+	}
+	return cmd
+}
+
+func listServiceAccounts(cmd *cobra.Command, options ServiceAccountsOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	serviceAccounts, err := spinClient.ListServiceAccounts(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	return gateClient.UI.JsonOutput(serviceAccounts)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func getRootCmdForTest() *cobra.Command {
+	rootCmd := &cobra.Command{}
+	rootCmd.PersistentFlags().String("config", "", "config file (default is $HOME/.spin/config)")
+	rootCmd.PersistentFlags().String("gate-endpoint", "", "Gate (API server) endpoint. Default http://localhost:8084")
+	rootCmd.PersistentFlags().Bool("insecure", false, "Ignore Certificate Errors")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Squelch non-essential output")
+	rootCmd.PersistentFlags().Bool("no-color", false, "Disable color")
+	rootCmd.PersistentFlags().String("color", "auto", "When to color output")
+	rootCmd.PersistentFlags().String("output", "", "Configure output formatting")
+	rootCmd.PersistentFlags().String("proxy-url", "", "HTTP(S) proxy to reach Gate through")
+	rootCmd.PersistentFlags().String("no-proxy", "", "Hosts that bypass the proxy")
+	rootCmd.PersistentFlags().StringArray("header", []string{}, "Header to add to every Gate request")
+	rootCmd.PersistentFlags().Duration("request-timeout", 0, "Timeout for each request to Gate")
+	rootCmd.PersistentFlags().Int("max-retries", 0, "Number of times to retry transient Gate failures")
+	rootCmd.PersistentFlags().Count("verbose", "Log Gate requests to stderr")
+	rootCmd.PersistentFlags().String("trace-file", "", "Write Gate requests to an HTTP Archive file")
+	return rootCmd
+}
+
+func TestServiceAccounts_basic(t *testing.T) {
+	ts := testGateServiceAccountsSuccess()
+	defer ts.Close()
+
+	currentCmd := NewServiceAccountsCmd(authOptions{})
+	rootCmd := getRootCmdForTest()
+	authCmd := NewAuthCmd(os.Stdout)
+	authCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(authCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+
+	args := []string{"auth", "service-accounts", "--gate-endpoint=" + ts.URL}
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	var accounts []string
+	if err := json.Unmarshal(out.Bytes(), &accounts); err != nil {
+		t.Fatalf("Expected a JSON list of accounts, got %q: %v", out.String(), err)
+	}
+	if strings.Join(accounts, ",") != "deploy-bot@example.com,release-bot@example.com" {
+		t.Fatalf("Expected the service accounts Gate returned, got %v", accounts)
+	}
+}
+
+func TestServiceAccounts_fail(t *testing.T) {
+	ts := GateServerFail()
+	defer ts.Close()
+
+	currentCmd := NewServiceAccountsCmd(authOptions{})
+	rootCmd := getRootCmdForTest()
+	authCmd := NewAuthCmd(os.Stdout)
+	authCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(authCmd)
+
+	args := []string{"auth", "service-accounts", "--gate-endpoint=" + ts.URL}
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+}
+
+// testGateServiceAccountsSuccess spins up a local http server that we will configure the GateClient
+// to direct requests to. Responds with a 200 and a list of service accounts.
+func testGateServiceAccountsSuccess() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/auth/user/serviceAccounts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, strings.TrimSpace(serviceAccountsJson))
+	}))
+	return httptest.NewServer(mux)
+}
+
+// GateServerFail spins up a local http server that we will configure the GateClient
+// to direct requests to. Responds with a 500 InternalServerError.
+func GateServerFail() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}))
+}
+
+const serviceAccountsJson = `
+[
+  "deploy-bot@example.com",
+  "release-bot@example.com"
+]
+`
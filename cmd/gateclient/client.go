@@ -12,374 +12,165 @@
 //   See the License for the specific language governing permissions and
 //   limitations under the License.
 
+// Package gateclient creates Gate clients and UIs for spin commands from
+// the global flags. The clients themselves are provided by
+// github.com/spinnaker/spin/gateclient, which doesn't depend on cobra.
 package gateclient
 
 import (
-	"bufio"
-	"context"
-	"crypto/rand"
-	"crypto/tls"
-	"crypto/x509"
-	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/http/cookiejar"
-	_ "net/http/pprof"
-	"os"
-	"os/user"
-	"path/filepath"
 	"strings"
 
-	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"gopkg.in/yaml.v2"
-
+	"github.com/spinnaker/spin/gateclient"
 	"github.com/spinnaker/spin/util"
-
-
-	"github.com/spinnaker/spin/config"
-	gate "github.com/spinnaker/spin/gateapi"
-	"github.com/spinnaker/spin/version"
-	"golang.org/x/oauth2"
-	"encoding/base64"
-	"crypto/sha256"
 )
 
-// GatewayClient is the wrapper with authentication
-type GatewayClient struct {
-	// The exported fields below should be set by anyone using a command
-	// with an GatewayClient field. These are expected to be set externally
-	// (not from within the command itself).
-
-	// Generate Gate Api client.
-	*gate.APIClient
-
-	// Spin CLI configuration.
-	Config config.Config
-
-	// Context for OAuth2 access token.
-	Context context.Context
-
-	// This is the set of flags global to the command parser.
-	gateEndpoint string
-
-	ignoreCertErrors bool
-
-	// Location of the spin config.
-	configLocation string
-
-	// Raw Http Client to do OAuth2 login.
-	httpClient *http.Client
-
-}
-
-func (m *GatewayClient) GateEndpoint() string {
-	if m.Config.Gate.Endpoint == "" && m.gateEndpoint == "" {
-		return "http://localhost:8084"
-	}
-	if m.gateEndpoint != "" {
-		return m.gateEndpoint
-	}
-	return m.Config.Gate.Endpoint
-}
-
-// Create new spinnaker gateway client with flag
-func NewGateClient(flags *pflag.FlagSet) (*GatewayClient, error) {
-	err := configureOutput(flags)
+// NewGateClient creates a Gate client with the command's flags. Requests
+// made with the client's Context are aborted when the command's context is
+// canceled. The client's UI writes results to the command's output and
+// diagnostics to its error output.
+func NewGateClient(cmd *cobra.Command) (*gateclient.GatewayClient, error) {
+	flags := cmd.InheritedFlags()
+	ui, err := NewUI(cmd)
 	if err != nil {
 		return nil, err
 	}
 
-	gateClient, err := createClient(flags)
+	options, err := optionsFromFlags(flags)
 	if err != nil {
 		return nil, err
 	}
-
-	err = userConfig(flags, gateClient)
+	options.UI = ui
+	gateClient, err := gateclient.New(cmd.Context(), options)
 	if err != nil {
 		return nil, err
 	}
+	return gateClient, nil
+}
 
-	// Api client initialization.
-	httpClient, err := gateClient.initializeClient()
+// NewContextGateClient creates a Gate client for the named context of the
+// spin config, for commands that talk to a second Spinnaker. The global
+// flags apply, except --gate-endpoint, which names the default instance.
+func NewContextGateClient(cmd *cobra.Command, contextName string) (*gateclient.GatewayClient, error) {
+	ui, err := NewUI(cmd)
 	if err != nil {
-		util.UI.Error("Could not initialize http client, failing.")
 		return nil, err
 	}
-	gateClient.httpClient = httpClient
 
-	err = gateClient.authenticateOAuth2()
+	options, err := optionsFromFlags(cmd.InheritedFlags())
 	if err != nil {
-		util.UI.Error("OAuth2 Authentication failed.")
 		return nil, err
 	}
-
-	cfg := &gate.Configuration{
-		BasePath:      gateClient.GateEndpoint(),
-		DefaultHeader: make(map[string]string),
-		UserAgent:     fmt.Sprintf("%s/%s", version.UserAgent, version.String()),
-		HTTPClient:    httpClient,
+	options.UI = ui
+	options.ContextName = contextName
+	options.GateEndpoint = ""
+	gateClient, err := gateclient.New(cmd.Context(), options)
+	if err != nil {
+		return nil, err
 	}
-	gateClient.APIClient = gate.NewAPIClient(cfg)
 	return gateClient, nil
 }
 
-func userConfig(flags *pflag.FlagSet, gateClient *GatewayClient) error {
-	configLocationFlag, err := flags.GetString("config")
+// optionsFromFlags reads the global flags that configure the Gate client.
+func optionsFromFlags(flags *pflag.FlagSet) (gateclient.Options, error) {
+	configPath, err := flags.GetString("config")
 	if err != nil {
-		return err
+		return gateclient.Options{}, err
 	}
-	if configLocationFlag != "" {
-		gateClient.configLocation = configLocationFlag
-	} else {
-		userHome := ""
-		usr, err := user.Current()
-		if err != nil {
-			// Fallback by trying to read $HOME
-			userHome = os.Getenv("HOME")
-			if userHome != "" {
-				err = nil
-			} else {
-				util.UI.Error("Could not read current user from environment, failing.")
-				return err
-			}
-		} else {
-			userHome = usr.HomeDir
-		}
-		gateClient.configLocation = filepath.Join(userHome, ".spin", "config")
-	}
-	yamlFile, err := ioutil.ReadFile(gateClient.configLocation)
+	gateEndpoint, err := flags.GetString("gate-endpoint")
 	if err != nil {
-		util.UI.Warn(fmt.Sprintf("Could not read configuration file from %s.", gateClient.configLocation))
+		return gateclient.Options{}, err
 	}
-
-	if yamlFile != nil {
-		err = yaml.UnmarshalStrict([]byte(os.ExpandEnv(string(yamlFile))), &gateClient.Config)
-		if err != nil {
-			util.UI.Error(fmt.Sprintf("Could not deserialize config file with contents: %s, failing.", yamlFile))
-			return err
-		}
-	} else {
-		gateClient.Config = config.Config{}
+	ignoreCertErrors, err := flags.GetBool("insecure")
+	if err != nil {
+		return gateclient.Options{}, err
 	}
-	return nil
-}
-
-func createClient(flags *pflag.FlagSet) (*GatewayClient, error) {
-	gateEndpoint, err := flags.GetString("gate-endpoint")
+	proxyUrl, err := flags.GetString("proxy-url")
 	if err != nil {
-		return nil, err
+		return gateclient.Options{}, err
 	}
-	ignoreCertErrors, err := flags.GetBool("insecure")
+	noProxy, err := flags.GetString("no-proxy")
 	if err != nil {
-		return nil, err
+		return gateclient.Options{}, err
 	}
-	return &GatewayClient{
-		gateEndpoint:     gateEndpoint,
-		ignoreCertErrors: ignoreCertErrors,
-	}, nil
-}
-
-func configureOutput(flags *pflag.FlagSet) (error) {
-	quiet, err := flags.GetBool("quiet")
+	headerFlags, err := flags.GetStringArray("header")
 	if err != nil {
-		return err
+		return gateclient.Options{}, err
 	}
-	nocolor, err := flags.GetBool("no-color")
+	headers, err := parseHeaders(headerFlags)
 	if err != nil {
-		return err
+		return gateclient.Options{}, err
 	}
-	outputFormat, err := flags.GetString("output")
+	requestTimeout, err := flags.GetDuration("request-timeout")
 	if err != nil {
-		return err
+		return gateclient.Options{}, err
 	}
-	util.InitUI(quiet, nocolor, outputFormat)
-	return nil
-}
-
-func (m *GatewayClient) initializeClient() (*http.Client, error) {
-	auth := m.Config.Auth
-	cookieJar, _ := cookiejar.New(nil)
-	client := http.Client{
-		Jar: cookieJar,
+	maxRetries, err := flags.GetInt("max-retries")
+	if err != nil {
+		return gateclient.Options{}, err
 	}
-
-	if m.ignoreCertErrors {
-		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	if maxRetries < 0 {
+		return gateclient.Options{}, fmt.Errorf("--max-retries must not be negative, got %d", maxRetries)
 	}
-
-	if auth != nil && auth.Enabled && auth.X509 != nil {
-		X509 := auth.X509
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{},
-		}
-
-		if !X509.IsValid() {
-			// Misconfigured.
-			return nil, errors.New("Incorrect x509 auth configuration.\nMust specify certPath/keyPath or cert/key pair.")
-		}
-
-		if X509.CertPath != "" && X509.KeyPath != "" {
-			certPath, err := homedir.Expand(X509.CertPath)
-			if err != nil {
-				return nil, err
-			}
-			keyPath, err := homedir.Expand(X509.KeyPath)
-			if err != nil {
-				return nil, err
-			}
-
-			cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-			if err != nil {
-				return nil, err
-			}
-
-			clientCA, err := ioutil.ReadFile(certPath)
-			if err != nil {
-				return nil, err
-			}
-
-			return m.initializeX509Config(client, clientCA, cert), nil
-		} else if X509.Cert != "" && X509.Key != "" {
-			certBytes := []byte(X509.Cert)
-			keyBytes := []byte(X509.Key)
-			cert, err := tls.X509KeyPair(certBytes, keyBytes)
-			if err != nil {
-				return nil, err
-			}
-
-			return m.initializeX509Config(client, certBytes, cert), nil
-		} else {
-			// Misconfigured.
-			return nil, errors.New("Incorrect x509 auth configuration.\nMust specify certPath/keyPath or cert/key pair.")
-		}
-	} else if auth != nil && auth.Enabled && auth.Basic != nil {
-		if !auth.Basic.IsValid() {
-			return nil, errors.New("Incorrect Basic auth configuration. Must include username and password.")
-		}
-		m.Context = context.WithValue(context.Background(), gate.ContextBasicAuth, gate.BasicAuth{
-			UserName: auth.Basic.Username,
-			Password: auth.Basic.Password,
-		})
-		return &client, nil
-	} else {
-		return &client, nil
+	verbosity, err := flags.GetCount("verbose")
+	if err != nil {
+		return gateclient.Options{}, err
 	}
+	traceFile, err := flags.GetString("trace-file")
+	if err != nil {
+		return gateclient.Options{}, err
+	}
+	return gateclient.Options{
+		ConfigPath:     configPath,
+		GateEndpoint:   gateEndpoint,
+		Insecure:       ignoreCertErrors,
+		ProxyUrl:       proxyUrl,
+		NoProxy:        noProxy,
+		Headers:        headers,
+		RequestTimeout: requestTimeout,
+		MaxRetries:     maxRetries,
+		Verbosity:      verbosity,
+		TraceFile:      traceFile,
+	}, nil
 }
 
-func (m *GatewayClient) initializeX509Config(client http.Client, clientCA []byte, cert tls.Certificate) *http.Client {
-	clientCertPool := x509.NewCertPool()
-	clientCertPool.AppendCertsFromPEM(clientCA)
-
-	client.Transport.(*http.Transport).TLSClientConfig.MinVersion = tls.VersionTLS12
-	client.Transport.(*http.Transport).TLSClientConfig.PreferServerCipherSuites = true
-	client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{cert}
-	if m.ignoreCertErrors {
-		client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+// NewUI creates a command's UI from the global output flags. Commands that
+// talk to Gate use the UI of their GatewayClient instead.
+func NewUI(cmd *cobra.Command) (*util.ColorizeUi, error) {
+	flags := cmd.InheritedFlags()
+	quiet, err := flags.GetBool("quiet")
+	if err != nil {
+		return nil, err
 	}
-	return &client
-}
-
-func (m *GatewayClient) authenticateOAuth2() error {
-	auth := m.Config.Auth
-	if auth != nil && auth.Enabled && auth.OAuth2 != nil {
-		OAuth2 := auth.OAuth2
-		if !OAuth2.IsValid() {
-			// TODO(jacobkiefer): Improve this error message.
-			return errors.New("incorrect OAuth2 auth configuration")
-		}
-
-		config := &oauth2.Config{
-			ClientID:     OAuth2.ClientId,
-			ClientSecret: OAuth2.ClientSecret,
-			RedirectURL:  "http://localhost:8085",
-			Scopes:       OAuth2.Scopes,
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  OAuth2.AuthUrl,
-				TokenURL: OAuth2.TokenUrl,
-			},
-		}
-		var newToken *oauth2.Token
-		var err error
-
-		if auth.OAuth2.CachedToken != nil {
-			// Look up cached credentials to save oauth2 roundtrip.
-			token := auth.OAuth2.CachedToken
-			tokenSource := config.TokenSource(context.Background(), token)
-			newToken, err = tokenSource.Token()
-			if err != nil {
-				util.UI.Error(fmt.Sprintf("Could not refresh token from source: %v", tokenSource))
-				return err
-			}
-		} else {
-			// Do roundtrip.
-			http.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				code := r.FormValue("code")
-				fmt.Fprintln(w, code)
-			}))
-			go http.ListenAndServe(":8085", nil)
-			// Note: leaving server connection open for scope of request, will be reaped on exit.
-
-			verifier, verifierCode, err := generateCodeVerifier()
-			if err != nil {
-				return err
-			}
-
-			codeVerifier := oauth2.SetAuthURLParam("code_verifier", verifier)
-			codeChallenge := oauth2.SetAuthURLParam("code_challenge", verifierCode)
-			challengeMethod := oauth2.SetAuthURLParam("code_challenge_method", "S256")
-
-			authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.ApprovalForce, challengeMethod, codeChallenge)
-			util.UI.Output(fmt.Sprintf("Navigate to %s and authenticate", authURL))
-			code := prompt()
-
-			newToken, err = config.Exchange(context.Background(), code, codeVerifier)
-			if err != nil {
-				return err
-			}
-		}
-
-		util.UI.Info("Caching oauth2 token.")
-		OAuth2.CachedToken = newToken
-		buf, _ := yaml.Marshal(&m.Config)
-		info, _ := os.Stat(m.configLocation)
-		ioutil.WriteFile(m.configLocation, buf, info.Mode())
-
-		m.login(newToken.AccessToken)
-		m.Context = context.Background()
+	noColor, err := flags.GetBool("no-color")
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
-
-func (m *GatewayClient) login(accessToken string) error {
-	loginReq, err := http.NewRequest("GET", m.GateEndpoint() + "/login", nil)
+	color, err := flags.GetString("color")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	loginReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	m.httpClient.Do(loginReq) // Login to establish session.
-	return nil
-}
-
-// generateCodeVerifier generates an OAuth2 code verifier
-// in accordance to https://www.oauth.com/oauth2-servers/pkce/authorization-request and
-// https://tools.ietf.org/html/rfc7636#section-4.1.
-func generateCodeVerifier() (verifier string, code string, err error) {
-	randomBytes := make([]byte, 64)
-	if _, err := rand.Read(randomBytes); err != nil {
-		util.UI.Error("Could not generate random string for code_verifier")
-		return "", "", err
+	if noColor {
+		color = util.ColorNever
 	}
-	verifier = base64.RawURLEncoding.EncodeToString(randomBytes)
-	verifierHash := sha256.Sum256([]byte(verifier))
-	code = base64.RawURLEncoding.EncodeToString(verifierHash[:]) // Slice for type conversion
-	return verifier, code, nil
+	outputFormat, err := flags.GetString("output")
+	if err != nil {
+		return nil, err
+	}
+	return util.NewUI(quiet, color, outputFormat, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
 }
 
-func prompt() string {
-	reader := bufio.NewReader(os.Stdin)
-	util.UI.Output("Paste authorization code:")
-	text, _ := reader.ReadString('\n')
-	return strings.TrimSpace(text)
+// parseHeaders parses 'Name: value' (or 'Name=value') header flags.
+func parseHeaders(headerFlags []string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, h := range headerFlags {
+		sep := strings.IndexAny(h, ":=")
+		if sep <= 0 {
+			return nil, fmt.Errorf("Could not parse header '%s', expected 'Name: value'", h)
+		}
+		headers[strings.TrimSpace(h[:sep])] = strings.TrimSpace(h[sep+1:])
+	}
+	return headers, nil
 }
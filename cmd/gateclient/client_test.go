@@ -0,0 +1,33 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"testing"
+)
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders([]string{"X-Tenant: blue", "X-Goog-Iap-Jwt-Assertion=abc:def"})
+	if err != nil {
+		t.Fatalf("Failed to parse headers: %s", err)
+	}
+	if headers["X-Tenant"] != "blue" || headers["X-Goog-Iap-Jwt-Assertion"] != "abc:def" {
+		t.Fatalf("Unexpected headers: %v", headers)
+	}
+
+	if _, err := parseHeaders([]string{"no-separator"}); err == nil {
+		t.Fatal("Expected header without a separator to fail")
+	}
+}
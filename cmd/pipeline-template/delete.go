@@ -15,12 +15,11 @@
 package pipeline_template
 
 import (
-	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
-	"net/http"
 )
 
 type DeleteOptions struct {
@@ -44,26 +43,21 @@ func NewDeleteCmd(pipelineTemplateOptions pipelineTemplateOptions) *cobra.Comman
 }
 
 func deletePipelineTemplate(cmd *cobra.Command, args []string) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
 	if len(args) == 0 || args[0] == "" {
-		return errors.New("pipeline template id required")
+		return util.NewUsageError("pipeline template id required")
 	}
 	id := args[0]
 
-	_, resp, err := gateClient.V2PipelineTemplatesControllerApi.DeleteUsingDELETE1(gateClient.Context, id, nil)
-
-	if err != nil {
+	if err := spinClient.DeletePipelineTemplate(cmd.Context(), id); err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("Encountered an error deleting pipeline template, status code: %d\n", resp.StatusCode)
-	}
-
-	util.UI.Info(util.Colorize().Color(fmt.Sprintf("[reset][bold][green]Pipeline template %s deleted", id)))
+	gateClient.UI.Success(fmt.Sprintf("Pipeline template %s deleted", id))
 	return nil
 }
@@ -15,11 +15,9 @@
 package pipeline_template
 
 import (
-	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
-	"github.com/spinnaker/spin/util"
-	"net/http"
 )
 
 type GetOptions struct {
@@ -51,24 +49,16 @@ func NewGetCmd(pipelineTemplateOptions pipelineTemplateOptions) *cobra.Command {
 }
 
 func getPipelineTemplate(cmd *cobra.Command, options GetOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
-	successPayload, resp, err := gateClient.V2PipelineTemplatesControllerApi.GetUsingGET1(gateClient.Context,
-		options.id)
-
+	template, err := spinClient.GetPipelineTemplate(cmd.Context(), options.id)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error getting pipeline template with id %s, status code: %d\n",
-			options.id,
-			resp.StatusCode)
-	}
-
-	util.UI.JsonOutput(successPayload, util.UI.OutputFormat)
-	return nil
+	return gateClient.UI.JsonOutput(template)
 }
@@ -22,7 +22,6 @@ import (
 "testing"
 
 "github.com/spf13/cobra"
-"github.com/spinnaker/spin/util"
 )
 
 func getRootCmdForTest() *cobra.Command {
@@ -32,8 +31,15 @@ func getRootCmdForTest() *cobra.Command {
 	rootCmd.PersistentFlags().Bool("insecure", false, "Ignore Certificate Errors")
 	rootCmd.PersistentFlags().Bool("quiet", false, "Squelch non-essential output")
 	rootCmd.PersistentFlags().Bool("no-color", false, "Disable color")
+	rootCmd.PersistentFlags().String("color", "auto", "When to color output")
 	rootCmd.PersistentFlags().String("output", "", "Configure output formatting")
-	util.InitUI(false, false, "")
+	rootCmd.PersistentFlags().String("proxy-url", "", "HTTP(S) proxy to reach Gate through")
+	rootCmd.PersistentFlags().String("no-proxy", "", "Hosts that bypass the proxy")
+	rootCmd.PersistentFlags().StringArray("header", []string{}, "Header to add to every Gate request")
+	rootCmd.PersistentFlags().Duration("request-timeout", 0, "Timeout for each request to Gate")
+	rootCmd.PersistentFlags().Int("max-retries", 0, "Number of times to retry transient Gate failures")
+	rootCmd.PersistentFlags().Count("verbose", "Log Gate requests to stderr")
+	rootCmd.PersistentFlags().String("trace-file", "", "Write Gate requests to an HTTP Archive file")
 	return rootCmd
 }
 
@@ -15,12 +15,9 @@
 package pipeline_template
 
 import (
-	"fmt"
-	"net/http"
-
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
-	"github.com/spinnaker/spin/util"
 )
 
 type ListOptions struct {
@@ -54,24 +51,16 @@ func NewListCmd(pipelineTemplateOptions pipelineTemplateOptions) *cobra.Command
 }
 
 func listPipelineTemplate(cmd *cobra.Command, options ListOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
-	successPayload, resp, err := gateClient.V2PipelineTemplatesControllerApi.ListUsingGET1(gateClient.Context,
-		map[string]interface{}{"scopes": options.scopes})
-
+	templates, err := spinClient.ListPipelineTemplates(cmd.Context(), *options.scopes)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error listing pipeline templates for scopes %v, status code: %d\n",
-			options.scopes,
-			resp.StatusCode)
-	}
-
-	util.UI.JsonOutput(successPayload, util.UI.OutputFormat)
-	return nil
+	return gateClient.UI.JsonOutput(templates)
 }
@@ -15,12 +15,10 @@
 package pipeline_template
 
 import (
-	"errors"
-	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
-	"net/http"
 )
 
 type PlanOptions struct {
@@ -52,31 +50,21 @@ func NewPlanCmd(pipelineTemplateOptions pipelineTemplateOptions) *cobra.Command
 }
 
 func planPipelineTemplate(cmd *cobra.Command, options PlanOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
 	configJson, err := util.ParseJsonFromFileOrStdin(options.configPath)
 	if err != nil {
 		return err
 	}
 
-	if _, exists := configJson["schema"]; !exists {
-		return errors.New("Required pipeline key 'schema' missing for templated pipeline config...\n")
-	}
-
-	successPayload, resp, err := gateClient.V2PipelineTemplatesControllerApi.PlanUsingPOST(gateClient.Context, configJson)
-
+	plan, err := spinClient.PlanPipelineTemplate(cmd.Context(), configJson)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error planning pipeline template config, status code: %d\n",
-			resp.StatusCode)
-	}
-
-	util.UI.JsonOutput(successPayload, util.UI.OutputFormat)
-	return nil
+	return gateClient.UI.JsonOutput(plan)
 }
@@ -15,11 +15,10 @@
 package pipeline_template
 
 import (
-	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
-	"net/http"
 )
 
 type SaveOptions struct {
@@ -53,57 +52,21 @@ func NewSaveCmd(pipelineTemplateOptions pipelineTemplateOptions) *cobra.Command
 }
 
 func savePipelineTemplate(cmd *cobra.Command, options SaveOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
 	templateJson, err := util.ParseJsonFromFileOrStdin(options.pipelineFile)
 	if err != nil {
 		return err
 	}
 
-	valid := true
-	if _, exists := templateJson["id"]; !exists {
-		util.UI.Error("Required pipeline template key 'id' missing...\n")
-		valid = false
-	}
-	if _, exists := templateJson["schema"]; !exists {
-		util.UI.Error("Required pipeline template key 'schema' missing...\n")
-		valid = false
-	}
-	if !valid {
-		return fmt.Errorf("Submitted pipeline is invalid: %s\n", templateJson)
-	}
-
-	templateId := templateJson["id"].(string)
-
-	_, resp, queryErr := gateClient.V2PipelineTemplatesControllerApi.GetUsingGET1(gateClient.Context, templateId)
-
-	var saveResp *http.Response
-	var saveErr error
-	if resp.StatusCode == http.StatusOK {
-		saveResp, saveErr = gateClient.V2PipelineTemplatesControllerApi.UpdateUsingPOST1(gateClient.Context, templateId, templateJson, nil)
-	} else if resp.StatusCode == http.StatusNotFound {
-		saveResp, saveErr = gateClient.V2PipelineTemplatesControllerApi.CreateUsingPOST1(gateClient.Context, templateJson)
-	} else {
-		if queryErr != nil {
-      return queryErr
-		}
-		return fmt.Errorf("Encountered an unexpected status code %d querying pipeline template with id %s\n",
-			resp.StatusCode, templateId)
-	}
-
-	if saveErr != nil {
-    return saveErr
-	}
-
-	if saveResp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("Encountered an error saving pipeline template %v, status code: %d\n",
-			templateJson,
-			saveResp.StatusCode)
+	if err := spinClient.SavePipelineTemplate(cmd.Context(), templateJson); err != nil {
+		return err
 	}
 
-	util.UI.Info(util.Colorize().Color(fmt.Sprintf("[reset][bold][green]Pipeline template save succeeded")))
+	gateClient.UI.Success("Pipeline template save succeeded")
 	return nil
 }
@@ -0,0 +1,233 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+type ApplyOptions struct {
+	*pipelineOptions
+	dir         string
+	prune       bool
+	dryRun      bool
+	concurrency int
+	lint        lintRuleOptions
+}
+
+var (
+	applyPipelineShort = "Create, update and optionally delete pipelines to match a directory of pipeline files"
+	applyPipelineLong  = `Save every pipeline in a directory, searched recursively for .json, .yml and
+.yaml files, or matching a glob. Each pipeline is created, updated or left unchanged by comparing
+it with the deployed pipelines of its application.
+
+With --prune, pipelines deployed in those applications that have no file are
+deleted. Use --dry-run to print the plan without changing anything.
+
+Pipelines are checked with the rules of 'spin pipeline lint' first; skip rules
+with --disable, or all of them with --skip-lint.`
+	applyPipelineExample = `  spin pipeline apply -d pipelines/ --dry-run
+  spin pipeline apply -d 'pipelines/app/*.json' --prune`
+)
+
+var applyActionSymbols = map[string]string{
+	client.PipelineCreated:   "+",
+	client.PipelineUpdated:   "~",
+	client.PipelineDeleted:   "-",
+	client.PipelineUnchanged: " ",
+}
+
+func NewApplyCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := ApplyOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "apply",
+		Short:   applyPipelineShort,
+		Long:    applyPipelineLong,
+		Example: applyPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return applyPipelines(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.dir, "dir", "d", "", "directory or glob of pipeline files")
+	cmd.PersistentFlags().BoolVar(&options.prune, "prune", false, "delete pipelines in the applications that have no file")
+	cmd.PersistentFlags().BoolVar(&options.dryRun, "dry-run", false, "print the plan without changing any pipelines")
+	cmd.PersistentFlags().IntVar(&options.concurrency, "concurrency", client.DefaultApplyConcurrency, "number of pipelines to save at once")
+	options.lint.addFlags(cmd.PersistentFlags())
+
+	return cmd
+}
+
+func applyPipelines(cmd *cobra.Command, options ApplyOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	if options.dir == "" {
+		return util.NewUsageError("required parameter 'dir' not set")
+	}
+	if options.concurrency < 1 {
+		return util.NewUsageError("--concurrency must be at least 1")
+	}
+	disabledRules, err := options.lint.disabledRules()
+	if err != nil {
+		return err
+	}
+
+	files, err := pipelineFiles(options.dir)
+	if err != nil {
+		return err
+	}
+	sources := []client.PipelineSource{}
+	for _, file := range files {
+		pipeline, err := readPipelineFile(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		sources = append(sources, client.PipelineSource{Source: file, Pipeline: pipeline})
+	}
+
+	changes, err := spinClient.PlanPipelines(cmd.Context(), sources, options.prune, disabledRules...)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if change.Action == client.PipelineUpdated {
+			if err := showPipelineDiff(gateClient.UI, change.Previous, change.Pipeline,
+				fmt.Sprintf("%s/%s (deployed)", change.Application, change.Name), change.Source); err != nil {
+				return err
+			}
+		}
+	}
+
+	var applyErr error
+	if !options.dryRun {
+		applyErr = spinClient.ApplyPipelines(cmd.Context(), changes, options.concurrency)
+	}
+
+	if gateClient.UI.OutputFormat.Json || gateClient.UI.OutputFormat.JsonPath != "" {
+		if err := gateClient.UI.JsonOutput(changes); err != nil {
+			return err
+		}
+	} else {
+		lines := []string{}
+		for _, change := range changes {
+			line := fmt.Sprintf("%s %s/%s", applyActionSymbols[change.Action], change.Application, change.Name)
+			if change.Source != "" {
+				line = fmt.Sprintf("%s (%s)", line, change.Source)
+			}
+			if change.Error != "" {
+				line = fmt.Sprintf("%s: failed", line)
+			}
+			lines = append(lines, line)
+		}
+		gateClient.UI.OutputDiff(strings.Join(lines, "\n"))
+	}
+
+	if applyErr != nil {
+		return applyErr
+	}
+	gateClient.UI.Success(applySummary(changes, options.dryRun))
+	return nil
+}
+
+// applySummary counts the changes by action.
+func applySummary(changes []*client.PipelineChange, dryRun bool) string {
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Action]++
+	}
+	if dryRun {
+		return fmt.Sprintf("Plan: %d to create, %d to update, %d to delete, %d unchanged",
+			counts[client.PipelineCreated], counts[client.PipelineUpdated], counts[client.PipelineDeleted], counts[client.PipelineUnchanged])
+	}
+	return fmt.Sprintf("Applied: %d created, %d updated, %d deleted, %d unchanged",
+		counts[client.PipelineCreated], counts[client.PipelineUpdated], counts[client.PipelineDeleted], counts[client.PipelineUnchanged])
+}
+
+// pipelineFiles returns the .json files under dir, or the files matching it
+// as a glob, sorted.
+func pipelineFiles(dir string) ([]string, error) {
+	files := []string{}
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isPipelineFile(d.Name()) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		matches, err := filepath.Glob(dir)
+		if err != nil {
+			return nil, util.NewUsageError("invalid glob %s: %v", dir, err)
+		}
+		files = matches
+	}
+
+	if len(files) == 0 {
+		return nil, util.NewUsageError("no pipeline files found in %s", dir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isPipelineFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".json", ".yml", ".yaml":
+		return true
+	}
+	return false
+}
+
+// readPipelineFile reads a pipeline from a JSON file, or a YAML file such as
+// 'spin pipeline export --format yaml' writes.
+func readPipelineFile(file string) (map[string]interface{}, error) {
+	if ext := filepath.Ext(file); ext != ".yml" && ext != ".yaml" {
+		return util.ParseJsonFromFileOrStdin(file)
+	}
+	content, err := util.ReadFileOrStdin(file)
+	if err != nil {
+		return nil, err
+	}
+	document, err := util.ParseYamlOrJson(content)
+	if err != nil {
+		return nil, util.NewValidationError("Could not parse pipeline: %v", err)
+	}
+	pipeline, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, util.NewValidationError("Pipeline must be an object")
+	}
+	return pipeline, nil
+}
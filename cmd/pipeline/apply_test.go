@@ -0,0 +1,243 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestPipelineApply_dryRun(t *testing.T) {
+	gate := newTestGateApply()
+	ts := httptest.NewServer(gate)
+	defer ts.Close()
+	dir := testApplyDir(t)
+	defer os.RemoveAll(dir)
+
+	out, errOut, err := runPipelineApply(ts, dir, "--dry-run", "--prune")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if len(gate.saved) != 0 || len(gate.deleted) != 0 {
+		t.Fatalf("Expected a dry run not to change pipelines, saved %v and deleted %v", gate.saved, gate.deleted)
+	}
+	expected := fmt.Sprintf("~ app/deploy (%[1]s/deploy.json)\n+ app/new (%[1]s/new.json)\n  app/same (%[1]s/same.json)\n- app/stale\n", dir)
+	if out != expected {
+		t.Fatalf("Expected plan:\n%s\ngot:\n%s", expected, out)
+	}
+	for _, expected := range []string{`+  "limitConcurrent": true`, "Plan: 1 to create, 1 to update, 1 to delete, 1 unchanged"} {
+		if !strings.Contains(errOut, expected) {
+			t.Errorf("Expected diagnostics to contain %q, got:\n%s", expected, errOut)
+		}
+	}
+}
+
+func TestPipelineApply_prune(t *testing.T) {
+	gate := newTestGateApply()
+	ts := httptest.NewServer(gate)
+	defer ts.Close()
+	dir := testApplyDir(t)
+	defer os.RemoveAll(dir)
+
+	_, errOut, err := runPipelineApply(ts, dir, "--prune")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	sort.Strings(gate.saved)
+	if strings.Join(gate.saved, ",") != "deploy,new" {
+		t.Fatalf("Expected deploy and new to be saved, got %v", gate.saved)
+	}
+	if strings.Join(gate.deleted, ",") != "stale" {
+		t.Fatalf("Expected stale to be deleted, got %v", gate.deleted)
+	}
+	if !strings.Contains(errOut, "Applied: 1 created, 1 updated, 1 deleted, 1 unchanged") {
+		t.Fatalf("Expected a summary, got:\n%s", errOut)
+	}
+}
+
+func TestPipelineApply_duplicate(t *testing.T) {
+	gate := newTestGateApply()
+	ts := httptest.NewServer(gate)
+	defer ts.Close()
+	dir := testApplyDir(t)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "copy.json"), []byte(`{"application": "app", "name": "new"}`), 0644)
+
+	_, _, err := runPipelineApply(ts, dir)
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+}
+
+func TestPipelineApply_exported(t *testing.T) {
+	var saved []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			saved = append(saved, r.URL.Path)
+			return
+		}
+		fmt.Fprintln(w, `[{"id": "p1", "index": 0, "application": "app", "name": "deploy", "updateTs": "1",
+  "stages": [{"id": "s1", "refId": "1", "type": "wait", "name": "Wait", "waitTime": 30, "requisiteStageRefIds": []}]}]`)
+	}))
+	defer ts.Close()
+
+	for _, format := range []string{"json", "yaml"} {
+		dir := t.TempDir()
+		runPipelineExport(t, ts, dir, "--format", format)
+
+		out, _, err := runPipelineApply(ts, dir)
+		if err != nil {
+			t.Fatalf("Applying %s export failed with: %s", format, err)
+		}
+		expected := fmt.Sprintf("  app/deploy (%s/deploy.%s)\n", dir, format)
+		if out != expected || len(saved) != 0 {
+			t.Fatalf("Expected the %s export to apply unchanged as:\n%s\ngot:\n%s\nand saved %v", format, expected, out, saved)
+		}
+	}
+}
+
+func TestPipelineApply_failureCode(t *testing.T) {
+	gate := newTestGateApply()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/pipelines" {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			http.NotFound(w, r)
+			return
+		}
+		gate.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	dir := testApplyDir(t)
+	defer os.RemoveAll(dir)
+
+	_, _, err := runPipelineApply(ts, dir)
+	if code := util.ExitCode(err); code != util.ExitAuth {
+		t.Fatalf("Expected exit code %d when every change is forbidden, got %d: %v", util.ExitAuth, code, err)
+	}
+	_, _, err = runPipelineApply(ts, dir, "--prune")
+	if code := util.ExitCode(err); code != util.ExitError {
+		t.Fatalf("Expected exit code %d for mixed failures, got %d: %v", util.ExitError, code, err)
+	}
+}
+
+func TestPipelineApply_noApplication(t *testing.T) {
+	gate := newTestGateApply()
+	ts := httptest.NewServer(gate)
+	defer ts.Close()
+	dir := testApplyDir(t)
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "orphan.json"), []byte(`{"name": "orphan", "stages": []}`), 0644)
+
+	_, _, err := runPipelineApply(ts, dir, "--disable", "required-keys")
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+	if !strings.Contains(err.Error(), "orphan.json") || strings.Contains(err.Error(), "<nil>") {
+		t.Fatalf("Expected the file without an application to be named, got: %v", err)
+	}
+}
+
+func runPipelineApply(ts *httptest.Server, dir string, extraArgs ...string) (string, string, error) {
+	args := append([]string{"pipeline", "apply", "--dir", dir, "--gate-endpoint", ts.URL}, extraArgs...)
+	currentCmd := NewApplyCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out, errOut bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), errOut.String(), err
+}
+
+// testApplyDir writes pipeline files for a new pipeline, a changed one and
+// an unchanged one.
+func testApplyDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pipelines")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %v", err)
+	}
+	files := map[string]string{
+		"new.json":    `{"application": "app", "name": "new", "stages": []}`,
+		"deploy.json": `{"application": "app", "name": "deploy", "stages": [], "limitConcurrent": true}`,
+		"same.json":   `{"application": "app", "name": "same", "stages": []}`,
+		"README.md":   "Not a pipeline.",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Could not write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// testGateApply deploys pipelines deploy, same and stale in application app,
+// recording the pipelines saved and deleted.
+type testGateApply struct {
+	mux     *http.ServeMux
+	lock    sync.Mutex
+	saved   []string
+	deleted []string
+}
+
+func newTestGateApply() *testGateApply {
+	gate := &testGateApply{mux: http.NewServeMux()}
+	gate.mux.HandleFunc("/applications/app/pipelineConfigs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[
+  {"id": "1", "index": 0, "application": "app", "name": "deploy", "stages": [], "updateTs": "1"},
+  {"id": "2", "index": 1, "application": "app", "name": "same", "stages": [], "updateTs": "1"},
+  {"id": "3", "index": 2, "application": "app", "name": "stale", "stages": []}
+]`)
+	})
+	gate.mux.HandleFunc("/applications/app/pipelineConfigs/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"id": "4", "application": "app", "name": "new"}`)
+	})
+	gate.mux.HandleFunc("/pipelines", func(w http.ResponseWriter, r *http.Request) {
+		var pipeline map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&pipeline)
+		gate.lock.Lock()
+		defer gate.lock.Unlock()
+		gate.saved = append(gate.saved, pipeline["name"].(string))
+	})
+	gate.mux.HandleFunc("/pipelines/app/", func(w http.ResponseWriter, r *http.Request) {
+		gate.lock.Lock()
+		defer gate.lock.Unlock()
+		gate.deleted = append(gate.deleted, strings.TrimPrefix(r.URL.Path, "/pipelines/app/"))
+	})
+	return gate
+}
+
+func (g *testGateApply) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+	"gopkg.in/yaml.v2"
+)
+
+type CopyOptions struct {
+	*pipelineOptions
+	fromApplication string
+	name            string
+	toApplication   string
+	toContext       string
+	newName         string
+	mappingFile     string
+	lint            lintRuleOptions
+}
+
+var (
+	copyPipelineShort = "Copy a pipeline to another application or Spinnaker"
+	copyPipelineLong  = `Copy a pipeline to another application, optionally on the Spinnaker named
+by a context of the spin config. The copy is saved without the source's id and
+index, with its application set to --to-app. Account references are rewritten
+by the mapping file, which lists source accounts and their replacements:
+
+  accounts:
+    sandbox-k8s: prod-k8s
+    sandbox-aws: prod-aws`
+	copyPipelineExample = `  spin pipeline copy --from-app sandbox --name deploy --to-app myapp
+  spin pipeline copy --from-app myapp --name deploy --to-app myapp --to-context prod --mapping prod-accounts.yml`
+)
+
+// copyMapping is the content of a --mapping file.
+type copyMapping struct {
+	Accounts map[string]string `yaml:"accounts"`
+}
+
+func NewCopyCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := CopyOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "copy",
+		Aliases: []string{"cp"},
+		Short:   copyPipelineShort,
+		Long:    copyPipelineLong,
+		Example: copyPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return copyPipeline(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&options.fromApplication, "from-app", "", "Spinnaker application the pipeline belongs to")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline to copy")
+	cmd.PersistentFlags().StringVar(&options.toApplication, "to-app", "", "Spinnaker application to copy the pipeline to")
+	cmd.PersistentFlags().StringVar(&options.toContext, "to-context", "", "context of the spin config naming the Spinnaker to copy to (default is the current one)")
+	cmd.PersistentFlags().StringVar(&options.newName, "new-name", "", "name of the copy (default is the pipeline's name)")
+	cmd.PersistentFlags().StringVar(&options.mappingFile, "mapping", "", "YAML or JSON file mapping source accounts to target accounts")
+	options.lint.addFlags(cmd.PersistentFlags())
+
+	return cmd
+}
+
+func copyPipeline(cmd *cobra.Command, options CopyOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	if options.fromApplication == "" || options.name == "" || options.toApplication == "" {
+		return util.NewUsageError("one of required parameters 'from-app', 'name' or 'to-app' not set")
+	}
+	if options.toContext == "" && options.fromApplication == options.toApplication &&
+		(options.newName == "" || options.newName == options.name) {
+		return util.NewUsageError("the copy would replace the pipeline, set --new-name, --to-app or --to-context")
+	}
+
+	disabledRules, err := options.lint.disabledRules()
+	if err != nil {
+		return err
+	}
+	copyOptions := client.CopyOptions{NewName: options.newName, DisabledLintRules: disabledRules}
+	if options.mappingFile != "" {
+		mapping, err := readCopyMapping(options.mappingFile)
+		if err != nil {
+			return err
+		}
+		copyOptions.Accounts = mapping.Accounts
+	}
+
+	var target *client.Client
+	if options.toContext != "" {
+		targetGateClient, err := gateclient.NewContextGateClient(cmd, options.toContext)
+		if err != nil {
+			return err
+		}
+		target = client.NewFromGateClient(targetGateClient)
+	}
+
+	result, err := spinClient.CopyPipeline(cmd.Context(), target, options.fromApplication, options.name, options.toApplication, copyOptions)
+	if err != nil {
+		return err
+	}
+	return reportSaveResult(gateClient.UI, result, "copy")
+}
+
+func readCopyMapping(path string) (*copyMapping, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mapping := &copyMapping{}
+	if err := yaml.UnmarshalStrict(content, mapping); err != nil {
+		return nil, util.NewUsageError("could not parse mapping file %s: %v", path, err)
+	}
+	return mapping, nil
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+const copySourcePipeline = `{
+  "id": "p1",
+  "index": 3,
+  "application": "sandbox",
+  "name": "deploy",
+  "updateTs": "1540000000000",
+  "stages": [
+    {"refId": "1", "type": "deployManifest", "account": "sandbox-k8s", "name": "Deploy"},
+    {"refId": "2", "type": "wait", "requisiteStageRefIds": ["1"], "waitTime": 30}
+  ]
+}`
+
+func TestPipelineCopy_basic(t *testing.T) {
+	var saved map[string]interface{}
+	ts := testGatePipelineCopyServer(copySourcePipeline, &saved)
+	defer ts.Close()
+
+	mappingFile, err := ioutil.TempFile("", "mapping*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(mappingFile.Name())
+	fmt.Fprintln(mappingFile, "accounts:\n  sandbox-k8s: prod-k8s")
+	mappingFile.Close()
+
+	err = runPipelineCopy("--to-app", "myapp", "--mapping", mappingFile.Name(), "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if saved["application"] != "myapp" || saved["name"] != "deploy" {
+		t.Fatalf("Expected deploy to be saved in myapp, got %v", saved)
+	}
+	for _, key := range []string{"id", "index", "updateTs"} {
+		if _, exists := saved[key]; exists {
+			t.Fatalf("Expected %s to be dropped, got %v", key, saved)
+		}
+	}
+	stage := saved["stages"].([]interface{})[0].(map[string]interface{})
+	if stage["account"] != "prod-k8s" {
+		t.Fatalf("Expected the account to be mapped, got %v", stage)
+	}
+}
+
+func TestPipelineCopy_toContext(t *testing.T) {
+	var source, target map[string]interface{}
+	sourceServer := testGatePipelineCopyServer(copySourcePipeline, &source)
+	defer sourceServer.Close()
+	targetServer := testGatePipelineCopyServer("", &target)
+	defer targetServer.Close()
+
+	configFile, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(configFile.Name())
+	fmt.Fprintf(configFile, "contexts:\n  prod:\n    gate:\n      endpoint: %s\n", targetServer.URL)
+	configFile.Close()
+
+	err = runPipelineCopy("--to-app", "sandbox", "--to-context", "prod", "--config", configFile.Name(), "--gate-endpoint", sourceServer.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if source != nil {
+		t.Fatalf("Expected nothing saved to the source, got %v", source)
+	}
+	if target["application"] != "sandbox" || target["name"] != "deploy" {
+		t.Fatalf("Expected deploy to be saved to the target, got %v", target)
+	}
+}
+
+func TestPipelineCopy_unknownContext(t *testing.T) {
+	var saved map[string]interface{}
+	ts := testGatePipelineCopyServer(copySourcePipeline, &saved)
+	defer ts.Close()
+
+	err := runPipelineCopy("--to-app", "myapp", "--to-context", "prod", "--config", "/dev/null", "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+}
+
+func TestPipelineCopy_sameTarget(t *testing.T) {
+	var saved map[string]interface{}
+	ts := testGatePipelineCopyServer(copySourcePipeline, &saved)
+	defer ts.Close()
+
+	err := runPipelineCopy("--to-app", "sandbox", "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+	if saved != nil {
+		t.Fatalf("Expected nothing saved, got %v", saved)
+	}
+}
+
+func runPipelineCopy(extraArgs ...string) error {
+	args := append([]string{"pipeline", "copy", "--from-app", "sandbox", "--name", "deploy"}, extraArgs...)
+	currentCmd := NewCopyCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// testGatePipelineCopyServer serves sandbox's deploy pipeline if source is
+// set, and no other pipelines. Saved pipelines are decoded into saved.
+func testGatePipelineCopyServer(source string, saved *map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/applications/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if source == "" || r.URL.Path != "/applications/sandbox/pipelineConfigs/deploy" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, source)
+	}))
+	mux.Handle("/pipelines", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(saved)
+	}))
+	return httptest.NewServer(mux)
+}
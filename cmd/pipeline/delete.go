@@ -15,11 +15,16 @@
 package pipeline
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
-	"net/http"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
 )
@@ -28,12 +33,26 @@ type DeleteOptions struct {
 	*pipelineOptions
 	output      string
 	application string
-	name        string
+	names       []string
+	selector    string
+	yes         bool
+	backupDir   string
 }
 
 var (
-	deletePipelineShort   = "Delete the provided pipeline"
-	deletePipelineLong    = "Delete the provided pipeline"
+	deletePipelineShort   = "Delete the provided pipelines"
+	deletePipelineLong    = `Delete pipelines of an application, named with --name or as arguments, or
+matched by --selector. A selector is a comma-separated list of requirements on
+the pipelines' fields, each 'field=value', 'field!=value' or 'field=~regex',
+where nested fields are joined with dots. Values may contain commas, except
+before text that looks like another requirement.
+
+The pipelines to delete are listed and must be confirmed unless --yes is set.
+Each is first backed up to a JSON file, by default under ~/.spin/backups, that
+'spin pipeline save --file' restores.`
+	deletePipelineExample = `  spin pipeline delete -a app -n one
+  spin pipeline delete -a app one two three
+  spin pipeline delete -a app --selector 'name=~^tmp-,disabled=true' --yes`
 )
 
 func NewDeleteCmd(pipelineOptions pipelineOptions) *cobra.Command {
@@ -41,40 +60,139 @@ func NewDeleteCmd(pipelineOptions pipelineOptions) *cobra.Command {
 		pipelineOptions: &pipelineOptions,
 	}
 	cmd := &cobra.Command{
-		Use:     "delete",
+		Use:     "delete [NAME...]",
 		Aliases: []string{"del"},
 		Short:   deletePipelineShort,
 		Long:    deletePipelineLong,
+		Example: deletePipelineExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			options.names = append(options.names, args...)
 			return deletePipeline(cmd, options)
 		},
 	}
 
-	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline lives in")
-	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline to delete")
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipelines live in")
+	cmd.PersistentFlags().StringArrayVarP(&options.names, "name", "n", []string{}, "name of a pipeline to delete")
+	cmd.PersistentFlags().StringVarP(&options.selector, "selector", "l", "", "delete the pipelines matching the selector, e.g. 'name=~^tmp-'")
+	cmd.PersistentFlags().BoolVarP(&options.yes, "yes", "y", false, "delete without asking for confirmation")
+	cmd.PersistentFlags().StringVar(&options.backupDir, "backup-dir", "", "directory to back deleted pipelines up to (default is ~/.spin/backups/<application>)")
 
 	return cmd
 }
 
 func deletePipeline(cmd *cobra.Command, options DeleteOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
+	ui := gateClient.UI
 
-	if options.application == "" || options.name == "" {
-		return errors.New("one of required parameters 'application' or 'name' not set")
+	if options.application == "" || (len(options.names) == 0 && options.selector == "") {
+		return util.NewUsageError("one of required parameters 'application' or 'name' not set")
+	}
+	if len(options.names) > 0 && options.selector != "" {
+		return util.NewUsageError("only one of pipeline names or 'selector' may be set")
 	}
-	resp, err := gateClient.PipelineControllerApi.DeletePipelineUsingDELETE(gateClient.Context, options.application, options.name)
 
+	pipelines, err := pipelinesToDelete(cmd, spinClient, options)
 	if err != nil {
 		return err
 	}
+	if len(pipelines) == 0 {
+		ui.Info(fmt.Sprintf("No pipelines in application %s match %s", options.application, options.selector))
+		return nil
+	}
+
+	if !options.yes {
+		query := fmt.Sprintf("The following pipelines will be deleted from application %s:\n", options.application)
+		for _, pipeline := range pipelines {
+			query += fmt.Sprintf("  %v\n", pipeline["name"])
+		}
+		answer, err := ui.Ask(fmt.Sprintf("%sDelete %d pipelines? [y/N]", query, len(pipelines)))
+		if err != nil || !strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y") {
+			return util.NewInterruptedError("Aborted, no pipelines were deleted")
+		}
+	}
+
+	backupDir := options.backupDir
+	if backupDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		backupDir = filepath.Join(home, ".spin", "backups", options.application)
+	}
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return err
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error deleting pipeline, status code: %d\n", resp.StatusCode)
+	used := map[string]bool{}
+	for i, pipeline := range pipelines {
+		name := fmt.Sprintf("%v", pipeline["name"])
+		backup, err := backupPipeline(pipeline, backupDir, used)
+		if err != nil {
+			return fmt.Errorf("Could not back up pipeline %s, %d of %d pipelines deleted: %w", name, i, len(pipelines), err)
+		}
+		if err := spinClient.DeletePipeline(cmd.Context(), options.application, name); err != nil {
+			return fmt.Errorf("%d of %d pipelines deleted: %w", i, len(pipelines), err)
+		}
+		ui.Info(fmt.Sprintf("Deleted pipeline %s, backed up to %s", name, backup))
 	}
 
-	util.UI.Info(util.Colorize().Color(fmt.Sprintf("[reset][bold][green]Pipeline deleted")))
+	if len(pipelines) == 1 {
+		ui.Success("Pipeline deleted")
+	} else {
+		ui.Success(fmt.Sprintf("%d pipelines deleted", len(pipelines)))
+	}
 	return nil
 }
+
+// pipelinesToDelete fetches the named pipelines, failing if any don't
+// exist, or the pipelines matching the selector.
+func pipelinesToDelete(cmd *cobra.Command, spinClient *client.Client, options DeleteOptions) ([]map[string]interface{}, error) {
+	pipelines := []map[string]interface{}{}
+	if options.selector == "" {
+		for _, name := range options.names {
+			pipeline, err := spinClient.FindPipeline(cmd.Context(), options.application, name)
+			if err != nil {
+				return nil, err
+			}
+			if pipeline == nil {
+				return nil, util.NewNotFoundError("Pipeline %s does not exist in application %s", name, options.application)
+			}
+			pipelines = append(pipelines, pipeline)
+		}
+		return pipelines, nil
+	}
+
+	matches, err := parseSelector(options.selector)
+	if err != nil {
+		return nil, err
+	}
+	all, err := spinClient.ListPipelines(cmd.Context(), options.application)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range all {
+		if pipeline, ok := p.(map[string]interface{}); ok && matches(pipeline) {
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+	return pipelines, nil
+}
+
+// backupPipeline writes the pipeline to a file in dir named after it and the
+// time, returning the file's path.
+func backupPipeline(pipeline map[string]interface{}, dir string, used map[string]bool) (string, error) {
+	name := fmt.Sprintf("%v-%s", pipeline["name"], time.Now().UTC().Format("20060102T150405Z"))
+	file := filepath.Join(dir, exportFileName(name, "json", used))
+	content, err := json.MarshalIndent(pipeline, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(file, append(content, '\n'), 0644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
@@ -15,17 +15,25 @@
 package pipeline
 
 import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/spinnaker/spin/util"
 )
 
 // TODO(jacobkiefer): This test overlaps heavily with pipeline_save_test.go,
 // consider factoring common testing code out.
 func TestPipelineDelete_basic(t *testing.T) {
-	ts := GateServerSuccess()
+	ts := testGatePipelineGetSuccess()
 	defer ts.Close()
 
-	args := []string{"pipeline", "delete", "--application", "app", "--name", "one", "--gate-endpoint", ts.URL}
+	args := []string{"pipeline", "delete", "--application", "app", "--name", "one", "--yes", "--backup-dir", t.TempDir(), "--gate-endpoint", ts.URL}
 	currentCmd := NewDeleteCmd(pipelineOptions{})
 	rootCmd := getRootCmdForTest()
 	pipelineCmd := NewPipelineCmd(os.Stdout)
@@ -110,3 +118,145 @@ func TestPipelineDelete_missingapp(t *testing.T) {
 		t.Fatalf("Command errantly succeeded. %s", err)
 	}
 }
+
+func TestPipelineDelete_confirm(t *testing.T) {
+	var deleted []string
+	ts := testGatePipelineDeleteServer(&deleted)
+	defer ts.Close()
+	backupDir := t.TempDir()
+
+	errOut, err := runPipelineDelete("y\n", "-a", "app", "tmp-one", "keep", "--backup-dir", backupDir, "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if !strings.Contains(errOut, "  tmp-one\n  keep\nDelete 2 pipelines?") {
+		t.Fatalf("Expected the pipelines to be listed for confirmation, got %q", errOut)
+	}
+	if strings.Join(deleted, ",") != "tmp-one,keep" {
+		t.Fatalf("Expected tmp-one and keep to be deleted, got %v", deleted)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "tmp-one-*.json"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected a backup of tmp-one, got %v", backups)
+	}
+	backup, err := util.ParseJsonFromFileOrStdin(backups[0])
+	if err != nil || backup["id"] != "p1" || backup["name"] != "tmp-one" {
+		t.Fatalf("Expected the backup to hold pipeline tmp-one, got %v (%v)", backup, err)
+	}
+}
+
+func TestPipelineDelete_declined(t *testing.T) {
+	var deleted []string
+	ts := testGatePipelineDeleteServer(&deleted)
+	defer ts.Close()
+
+	_, err := runPipelineDelete("n\n", "-a", "app", "-n", "tmp-one", "--backup-dir", t.TempDir(), "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitInterrupted {
+		t.Fatalf("Expected exit code %d when declined, got %d: %v", util.ExitInterrupted, code, err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("Expected nothing deleted, got %v", deleted)
+	}
+}
+
+func TestPipelineDelete_selector(t *testing.T) {
+	var deleted []string
+	ts := testGatePipelineDeleteServer(&deleted)
+	defer ts.Close()
+
+	_, err := runPipelineDelete("", "-a", "app", "--selector", "name=~^tmp-,disabled!=true", "--yes", "--backup-dir", t.TempDir(), "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if strings.Join(deleted, ",") != "tmp-one" {
+		t.Fatalf("Expected only tmp-one to be deleted, got %v", deleted)
+	}
+}
+
+func TestPipelineDelete_selectorRegexComma(t *testing.T) {
+	var deleted []string
+	ts := testGatePipelineDeleteServer(&deleted)
+	defer ts.Close()
+
+	_, err := runPipelineDelete("", "-a", "app", "--selector", "name=~^tmp-(one|two){1,2}$,disabled!=true", "--yes", "--backup-dir", t.TempDir(), "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if strings.Join(deleted, ",") != "tmp-one" {
+		t.Fatalf("Expected only tmp-one to be deleted, got %v", deleted)
+	}
+}
+
+func TestPipelineDelete_badSelector(t *testing.T) {
+	var deleted []string
+	ts := testGatePipelineDeleteServer(&deleted)
+	defer ts.Close()
+
+	_, err := runPipelineDelete("", "-a", "app", "--selector", "name", "--yes", "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+}
+
+func TestPipelineDelete_missing(t *testing.T) {
+	var deleted []string
+	ts := testGatePipelineDeleteServer(&deleted)
+	defer ts.Close()
+
+	_, err := runPipelineDelete("", "-a", "app", "keep", "gone", "--yes", "--backup-dir", t.TempDir(), "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitNotFound {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitNotFound, code, err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("Expected nothing deleted, got %v", deleted)
+	}
+}
+
+// runPipelineDelete runs pipeline delete with input on stdin, returning its
+// diagnostic output.
+func runPipelineDelete(input string, extraArgs ...string) (string, error) {
+	args := append([]string{"pipeline", "delete"}, extraArgs...)
+	currentCmd := NewDeleteCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var errOut bytes.Buffer
+	rootCmd.SetIn(strings.NewReader(input))
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return errOut.String(), err
+}
+
+// testGatePipelineDeleteServer serves pipelines tmp-one, tmp-two (disabled)
+// and keep of application app, recording the names of deleted pipelines.
+func testGatePipelineDeleteServer(deleted *[]string) *httptest.Server {
+	pipelines := map[string]string{
+		"tmp-one": `{"id": "p1", "application": "app", "name": "tmp-one"}`,
+		"tmp-two": `{"id": "p2", "application": "app", "name": "tmp-two", "disabled": true}`,
+		"keep":    `{"id": "p3", "application": "app", "name": "keep"}`,
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/applications/app/pipelineConfigs", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[%s, %s, %s]\n", pipelines["tmp-one"], pipelines["tmp-two"], pipelines["keep"])
+	}))
+	mux.Handle("/applications/app/pipelineConfigs/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pipeline, ok := pipelines[strings.TrimPrefix(r.URL.Path, "/applications/app/pipelineConfigs/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, pipeline)
+	}))
+	mux.Handle("/pipelines/app/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			*deleted = append(*deleted, strings.TrimPrefix(r.URL.Path, "/pipelines/app/"))
+		}
+	}))
+	return httptest.NewServer(mux)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+type DiffOptions struct {
+	*pipelineOptions
+	application  string
+	name         string
+	pipelineFile string
+	semantic     bool
+}
+
+var (
+	diffPipelineShort = "Compare a pipeline file with the deployed pipeline"
+	diffPipelineLong  = `Compare a pipeline file with the pipeline of the same name in Spinnaker.
+
+Keys managed by Spinnaker (updateTs, lastModifiedBy, index and ids the file does
+not set) are ignored. Exits with status 1 if the pipelines differ.`
+	diffPipelineExample = `  spin pipeline diff --file pipeline.json
+  spin pipeline diff --file pipeline.json --semantic`
+)
+
+func NewDiffCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := DiffOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   diffPipelineShort,
+		Long:    diffPipelineLong,
+		Example: diffPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return diffPipeline(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.pipelineFile, "file", "f", "", "path to the pipeline file (default stdin)")
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to (default from the file)")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline (default from the file)")
+	cmd.PersistentFlags().BoolVar(&options.semantic, "semantic", false, "list changed JSON paths instead of a unified diff")
+
+	return cmd
+}
+
+func diffPipeline(cmd *cobra.Command, options DiffOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	local, err := util.ParseJsonFromFileOrStdin(options.pipelineFile)
+	if err != nil {
+		return err
+	}
+	application, name := options.application, options.name
+	if application == "" {
+		application, _ = local["application"].(string)
+	}
+	if name == "" {
+		name, _ = local["name"].(string)
+	}
+	if application == "" || name == "" {
+		return util.NewUsageError("pipeline 'application' and 'name' must be set in the file or with --application and --name")
+	}
+
+	deployed, err := spinClient.FindPipeline(cmd.Context(), application, name)
+	if err != nil {
+		return err
+	}
+	normalizedLocal, normalizedDeployed, err := client.ComparablePipelines(local, deployed)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(normalizedLocal, normalizedDeployed) {
+		gateClient.UI.Success(fmt.Sprintf("Pipeline %s in application %s is up to date", name, application))
+		return nil
+	}
+
+	asJson := gateClient.UI.OutputFormat.Json || gateClient.UI.OutputFormat.JsonPath != ""
+	if asJson || options.semantic {
+		var from interface{} = normalizedDeployed
+		if deployed == nil {
+			// Report each key of a new pipeline as added.
+			from = map[string]interface{}{}
+		}
+		changes := util.JsonChanges(from, normalizedLocal)
+		if asJson {
+			if err := gateClient.UI.JsonOutput(changes); err != nil {
+				return err
+			}
+		} else {
+			lines := []string{}
+			for _, change := range changes {
+				lines = append(lines, change.String())
+			}
+			gateClient.UI.OutputDiff(strings.Join(lines, "\n"))
+		}
+	} else {
+		diff, err := util.JsonDiff(normalizedDeployed, normalizedLocal,
+			fmt.Sprintf("%s/%s (deployed)", application, name), describeSource(options.pipelineFile))
+		if err != nil {
+			return err
+		}
+		gateClient.UI.OutputDiff(diff)
+	}
+
+	if deployed == nil {
+		return util.NewDiffError("Pipeline %s does not exist in application %s", name, application)
+	}
+	return util.NewDiffError("Pipeline %s in application %s differs from %s", name, application, describeSource(options.pipelineFile))
+}
+
+func describeSource(file string) string {
+	if file == "" {
+		return "stdin"
+	}
+	return file
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestPipelineDiff_upToDate(t *testing.T) {
+	// Spinnaker-managed keys and generated ids are not differences.
+	deployed := strings.Replace(testPipelineJsonStr, `"triggers": [],`, `"index": 3, "triggers": [],`, 1)
+	deployed = strings.Replace(deployed, `"refId": "1",`, `"refId": "1", "id": "generated",`, 1)
+	deployed = strings.Replace(deployed, `"updateTs": "1520879791608"`, `"updateTs": "1620879791608"`, 1)
+	ts := testGatePipelineDiffServer(deployed)
+	defer ts.Close()
+
+	out, err := runPipelineDiff(t, ts, testPipelineJsonStr)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out != "" {
+		t.Fatalf("Expected no differences, got:\n%s", out)
+	}
+}
+
+func TestPipelineDiff_changed(t *testing.T) {
+	ts := testGatePipelineDiffServer(strings.Replace(testPipelineJsonStr, `"waitTime": 30`, `"waitTime": 60`, 1))
+	defer ts.Close()
+
+	out, err := runPipelineDiff(t, ts, testPipelineJsonStr)
+	if code := util.ExitCode(err); code != util.ExitError {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitError, code, err)
+	}
+	for _, expected := range []string{"--- app/pipeline1 (deployed)", `-      "waitTime": 60`, `+      "waitTime": 30`} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected diff to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestPipelineDiff_semantic(t *testing.T) {
+	ts := testGatePipelineDiffServer(strings.Replace(testPipelineJsonStr, `"waitTime": 30`, `"waitTime": 60`, 1))
+	defer ts.Close()
+
+	out, err := runPipelineDiff(t, ts, testPipelineJsonStr, "--semantic")
+	if code := util.ExitCode(err); code != util.ExitError {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitError, code, err)
+	}
+	if out != "~ stages[0].waitTime: 60 -> 30\n" {
+		t.Fatalf("Expected a single changed path, got:\n%s", out)
+	}
+}
+
+func TestPipelineDiff_semanticJson(t *testing.T) {
+	ts := testGatePipelineDiffServer(strings.Replace(testPipelineJsonStr, `"limitConcurrent": true`, `"limitConcurrent": false`, 1))
+	defer ts.Close()
+
+	out, err := runPipelineDiff(t, ts, testPipelineJsonStr, "--semantic", "--output", "json")
+	if code := util.ExitCode(err); code != util.ExitError {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitError, code, err)
+	}
+	var changes []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &changes); err != nil {
+		t.Fatalf("Expected a JSON list of changes, got %q: %v", out, err)
+	}
+	if len(changes) != 1 || changes[0]["from"] != false || changes[0]["to"] != true {
+		t.Fatalf("Expected limitConcurrent to change from false to true, got %v", changes)
+	}
+}
+
+func TestPipelineDiff_missing(t *testing.T) {
+	ts := testGatePipelineGetMissing()
+	defer ts.Close()
+
+	out, err := runPipelineDiff(t, ts, testPipelineJsonStr)
+	if code := util.ExitCode(err); code != util.ExitError {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitError, code, err)
+	}
+	if !strings.Contains(out, `+  "name": "pipeline1",`) {
+		t.Fatalf("Expected the whole pipeline to be added, got:\n%s", out)
+	}
+}
+
+func runPipelineDiff(t *testing.T, ts *httptest.Server, local string, extraArgs ...string) (string, error) {
+	tempFile := tempPipelineFile(local)
+	if tempFile == nil {
+		t.Fatal("Could not create temp pipeline file.")
+	}
+	defer os.Remove(tempFile.Name())
+
+	args := append([]string{"pipeline", "diff", "--file", tempFile.Name(), "--gate-endpoint", ts.URL}, extraArgs...)
+	currentCmd := NewDiffCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// testGatePipelineDiffServer responds to pipeline lookups with the deployed pipeline.
+func testGatePipelineDiffServer(deployed string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, deployed)
+	}))
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	disablePipelineShort   = "Disable a pipeline"
+	disablePipelineLong    = "Disable a pipeline so that it cannot run and its triggers do not fire"
+	disablePipelineExample = "  spin pipeline disable -a app -n deploy"
+)
+
+func NewDisableCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := EnableOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "disable",
+		Short:   disablePipelineShort,
+		Long:    disablePipelineLong,
+		Example: disablePipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setPipelineDisabled(cmd, options, true)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline")
+
+	return cmd
+}
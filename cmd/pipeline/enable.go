@@ -0,0 +1,88 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+type EnableOptions struct {
+	*pipelineOptions
+	application string
+	name        string
+}
+
+var (
+	enablePipelineShort   = "Enable a disabled pipeline"
+	enablePipelineLong    = "Enable a disabled pipeline so that it can run and its triggers fire"
+	enablePipelineExample = "  spin pipeline enable -a app -n deploy"
+)
+
+func NewEnableCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := EnableOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "enable",
+		Short:   enablePipelineShort,
+		Long:    enablePipelineLong,
+		Example: enablePipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setPipelineDisabled(cmd, options, false)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline")
+
+	return cmd
+}
+
+// setPipelineDisabled implements both enable and disable.
+func setPipelineDisabled(cmd *cobra.Command, options EnableOptions, disabled bool) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	if options.application == "" || options.name == "" {
+		return util.NewUsageError("one of required parameters 'application' or 'name' not set")
+	}
+
+	result, err := spinClient.SetPipelineDisabled(cmd.Context(), options.application, options.name, disabled)
+	if err != nil {
+		return err
+	}
+
+	if gateClient.UI.OutputFormat.Json || gateClient.UI.OutputFormat.JsonPath != "" {
+		return gateClient.UI.JsonOutput(result)
+	}
+	state := "enabled"
+	if disabled {
+		state = "disabled"
+	}
+	if result.Action == client.PipelineUnchanged {
+		gateClient.UI.Success(fmt.Sprintf("Pipeline %s is already %s", options.name, state))
+	} else {
+		gateClient.UI.Success(fmt.Sprintf("Pipeline %s %s", options.name, state))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestPipelineDisable_basic(t *testing.T) {
+	var updated map[string]interface{}
+	ts := testGatePipelineHistoryServer(&updated)
+	defer ts.Close()
+
+	args := []string{"pipeline", "disable", "--application", "app", "--name", "deploy", "--gate-endpoint", ts.URL}
+	currentCmd := NewDisableCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if updated["disabled"] != true {
+		t.Fatalf("Expected the pipeline to be disabled, got %v", updated)
+	}
+	if updated["id"] != "p1" || updated["lastModifiedBy"] != "carol" || len(updated["stages"].([]interface{})) != 1 {
+		t.Fatalf("Expected the rest of the pipeline to be preserved, got %v", updated)
+	}
+}
+
+func TestPipelineEnable_alreadyEnabled(t *testing.T) {
+	// The server rejects updates unless given somewhere to record them.
+	ts := testGatePipelineHistoryServer(nil)
+	defer ts.Close()
+
+	args := []string{"pipeline", "enable", "--application", "app", "--name", "deploy", "--gate-endpoint", ts.URL}
+	currentCmd := NewEnableCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var errOut bytes.Buffer
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if !strings.Contains(errOut.String(), "Pipeline deploy is already enabled") {
+		t.Fatalf("Expected the pipeline to be left alone, got:\n%s", errOut.String())
+	}
+}
+
+func TestPipelineEnable_missing(t *testing.T) {
+	ts := testGatePipelineGetMissing()
+	defer ts.Close()
+
+	args := []string{"pipeline", "enable", "--application", "app", "--name", "deploy", "--gate-endpoint", ts.URL}
+	currentCmd := NewEnableCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if code := util.ExitCode(err); code != util.ExitNotFound {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitNotFound, code, err)
+	}
+	if !strings.Contains(err.Error(), "Pipeline deploy does not exist in application app") {
+		t.Fatalf("Expected a clear not found error, got: %v", err)
+	}
+}
@@ -15,15 +15,13 @@
 package pipeline
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
-
 	"github.com/spinnaker/spin/util"
 )
 
@@ -33,6 +31,7 @@ type ExecuteOptions struct {
 	application   string
 	name          string
 	parameterFile string
+	wait          bool
 }
 
 var (
@@ -57,18 +56,20 @@ func NewExecuteCmd(pipelineOptions pipelineOptions) *cobra.Command {
 	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline lives in")
 	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline to execute")
 	cmd.PersistentFlags().StringVarP(&options.parameterFile, "parameter-file", "f", "", "file to load pipeline parameter values from")
+	cmd.PersistentFlags().BoolVar(&options.wait, "wait", false, "wait for the execution to complete; on Ctrl-C, offer to cancel it")
 
 	return cmd
 }
 
 func executePipeline(cmd *cobra.Command, options ExecuteOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
 	if options.application == "" || options.name == "" {
-		return errors.New("one of required parameters 'application' or 'name' not set")
+		return util.NewUsageError("one of required parameters 'application' or 'name' not set")
 	}
 	parameters := map[string]interface{}{}
 	parameters, err = util.ParseJsonFromFileOrStdin(options.parameterFile)
@@ -77,53 +78,56 @@ func executePipeline(cmd *cobra.Command, options ExecuteOptions) error {
 		parameters, err = nil, nil
 	}
 	if err != nil {
-		return fmt.Errorf("Could not parse supplied pipeline parameters: %v.\n", err)
-	}
-	trigger := map[string]interface{}{"type": "manual"}
-	if len(parameters) > 0 {
-		trigger["parameters"] = parameters
+		return util.NewValidationError("Could not parse supplied pipeline parameters: %v.\n", err)
 	}
 
-	_, resp, err := gateClient.PipelineControllerApi.InvokePipelineConfigUsingPOST1(gateClient.Context,
-		options.application,
-		options.name,
-		map[string]interface{}{"trigger": trigger})
-
+	runAs, err := cmd.InheritedFlags().GetString("run-as")
 	if err != nil {
-		return fmt.Errorf("Execute pipeline failed with response: %v and error: %s\n", resp, err)
-	}
-
-	if resp.StatusCode != http.StatusAccepted {
-		return fmt.Errorf("Encountered an error executing pipeline, status code: %d\n", resp.StatusCode)
+		return err
 	}
 
-	executions := make([]interface{}, 0)
-	attempts := 0
-	for len(executions) == 0 && attempts < 5 {
-		executions, resp, err = gateClient.ExecutionsControllerApi.SearchForPipelineExecutionsByTriggerUsingGET(
-			gateClient.Context,
-			options.application,
-			map[string]interface{}{
-				"pipelineName": options.name,
-				"statuses":     "RUNNING",
-			})
-		attempts += 1
-		time.Sleep(time.Duration(attempts*attempts) * time.Second)
-	}
+	refIds, err := spinClient.ExecutePipeline(cmd.Context(), options.application, options.name,
+		client.ExecuteOptions{Parameters: parameters, RunAs: runAs})
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return fmt.Errorf("Encountered an error querying pipeline execution, status code: %d\n", resp.StatusCode)
+	gateClient.UI.Output(fmt.Sprintf("%v", refIds))
+
+	if options.wait {
+		for _, id := range refIds {
+			if err := waitForExecution(cmd.Context(), gateClient.UI, spinClient, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// waitForExecution waits for the execution to complete. If spin is
+// interrupted while waiting, it offers to cancel the execution.
+func waitForExecution(ctx context.Context, ui *util.ColorizeUi, spinClient *client.Client, id string) error {
+	_, err := spinClient.WaitForExecution(ctx, id, client.WaitOptions{
+		OnStatus: func(status string) {
+			ui.Info(fmt.Sprintf("Pipeline execution %s is %s", id, ui.Status(status)))
+		},
+	})
+	if err != nil && ctx.Err() != nil {
+		return cancelInterruptedExecution(ctx, ui, spinClient, id)
 	}
-	if len(executions) == 0 {
-		return fmt.Errorf("Unable to start any executions, server response was: %v", resp)
+	return err
+}
+
+// cancelInterruptedExecution asks whether to cancel an execution that was
+// being waited on when spin was interrupted.
+func cancelInterruptedExecution(ctx context.Context, ui *util.ColorizeUi, spinClient *client.Client, id string) error {
+	answer, err := ui.Ask(fmt.Sprintf("\nInterrupted. Cancel pipeline execution %s? [y/N]", id))
+	if err != nil || !strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y") {
+		return util.NewInterruptedError("Interrupted, pipeline execution %s is still running", id)
 	}
 
-	refIds := make([]string, 0)
-	for _, execution := range executions {
-		refIds = append(refIds, execution.(map[string]interface{})["id"].(string))
+	// The command's context has been canceled; keep its values but not its cancellation.
+	if err := spinClient.CancelExecution(context.WithoutCancel(ctx), id, "Canceled from spin"); err != nil {
+		return err
 	}
-	util.UI.Output(fmt.Sprintf("%v", refIds))
-	return nil
+	return util.NewInterruptedError("Interrupted, canceled pipeline execution %s", id)
 }
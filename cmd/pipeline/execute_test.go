@@ -15,6 +15,8 @@
 package pipeline
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,6 +26,7 @@ import (
 	"testing"
 
 	gate "github.com/spinnaker/spin/gateapi"
+	"github.com/spinnaker/spin/util"
 )
 
 // TODO(jacobkiefer): This test overlaps heavily with pipeline_save_test.go,
@@ -142,21 +145,183 @@ func TestPipelineExecute_missingapp(t *testing.T) {
 	}
 }
 
+func TestPipelineExecute_runAs(t *testing.T) {
+	var trigger map[string]interface{}
+	ts := testGatePipelineExecuteServer(&trigger)
+	defer ts.Close()
+
+	args := []string{"pipeline", "execute", "--application", "app", "--name", "one", "--run-as", "deploy-bot@example.com", "--gate-endpoint", ts.URL}
+	currentCmd := NewExecuteCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if trigger["runAsUser"] != "deploy-bot@example.com" {
+		t.Fatalf("Expected the trigger to run as deploy-bot@example.com, got %v", trigger)
+	}
+}
+
+func TestPipelineExecute_runAsNotPermitted(t *testing.T) {
+	ts := testGatePipelineExecuteSuccess()
+	defer ts.Close()
+
+	args := []string{"pipeline", "execute", "--application", "app", "--name", "one", "--run-as", "someone-else@example.com", "--gate-endpoint", ts.URL}
+	currentCmd := NewExecuteCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatalf("Expected execution as an unpermitted service account to fail")
+	}
+}
+
+func TestPipelineExecute_wait(t *testing.T) {
+	ts := testGatePipelineExecuteSuccess()
+	defer ts.Close()
+
+	args := []string{"pipeline", "execute", "--application", "app", "--name", "one", "--wait", "--gate-endpoint", ts.URL}
+	currentCmd := NewExecuteCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+}
+
+func TestPipelineExecute_waitColor(t *testing.T) {
+	ts := testGatePipelineExecuteSuccess()
+	defer ts.Close()
+
+	args := []string{"pipeline", "execute", "--application", "app", "--name", "one", "--wait", "--color", "always", "--gate-endpoint", ts.URL}
+	currentCmd := NewExecuteCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out, errOut bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if !strings.Contains(errOut.String(), "\x1b[32mSUCCEEDED") {
+		t.Fatalf("Expected a green SUCCEEDED status, got %q", errOut.String())
+	}
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Fatalf("Expected uncolored results, got %q", out.String())
+	}
+}
+
+func TestPipelineExecute_waitInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	canceled := false
+	ts := testGatePipelineExecuteRunning(cancel, &canceled)
+	defer ts.Close()
+
+	// Parameters are read from stdin unless a file is given.
+	tempFile := tempPipelineFile("{}")
+	if tempFile == nil {
+		t.Fatal("Could not create temp parameter file.")
+	}
+	defer os.Remove(tempFile.Name())
+
+	args := []string{"pipeline", "execute", "--application", "app", "--name", "one", "--parameter-file", tempFile.Name(), "--wait", "--gate-endpoint", ts.URL}
+	currentCmd := NewExecuteCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	// Answer the cancellation prompt.
+	rootCmd.SetIn(strings.NewReader("y\n"))
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.ExecuteContext(ctx)
+	if code := util.ExitCode(err); code != util.ExitInterrupted {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInterrupted, code, err)
+	}
+	if !canceled {
+		t.Fatalf("Expected the interrupted execution to be canceled")
+	}
+}
+
 // testGatePipelineExecuteSuccess spins up a local http server that we will configure the GateClient
 // to direct requests to. Responds with successful responses to pipeline execute API calls.
 func testGatePipelineExecuteSuccess() *httptest.Server {
+	return testGatePipelineExecuteServer(nil)
+}
+
+// testGatePipelineExecuteServer is testGatePipelineExecuteSuccess, decoding
+// the trigger of each execution, which Gate takes as the request body, into
+// trigger if it is set.
+func testGatePipelineExecuteServer(trigger *map[string]interface{}) *httptest.Server {
 	mux := http.NewServeMux()
 	mux.Handle("/pipelines/app/one", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if trigger != nil {
+			*trigger = body
+		}
+		if runAs, exists := body["runAsUser"]; exists && runAs != "deploy-bot@example.com" {
+			http.Error(w, "Unexpected runAsUser", http.StatusBadRequest)
+			return
+		}
+
 		resp := gate.ResponseEntity{StatusCode: "201 Accepted", StatusCodeValue: 201}
 		b, _ := json.Marshal(&resp)
 
 		w.WriteHeader(http.StatusAccepted)
 		fmt.Fprintln(w, string(b)) // Write empty 201.
 	}))
+	mux.Handle("/auth/user/serviceAccounts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `["deploy-bot@example.com"]`)
+	}))
 	mux.Handle("/applications/app/executions/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintln(w, strings.TrimSpace(executions))
 	}))
+	mux.Handle("/pipelines/asdflkj", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"id": "asdflkj", "status": "SUCCEEDED"}`)
+	}))
+	return httptest.NewServer(mux)
+}
+
+// testGatePipelineExecuteRunning starts an execution that never finishes,
+// calling interrupt when it is first polled to simulate Ctrl-C.
+func testGatePipelineExecuteRunning(interrupt func(), canceled *bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/pipelines/app/one", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintln(w, "{}")
+	}))
+	mux.Handle("/applications/app/executions/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, strings.TrimSpace(executions))
+	}))
+	mux.Handle("/pipelines/asdflkj", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		interrupt()
+		fmt.Fprintln(w, `{"id": "asdflkj", "status": "RUNNING"}`)
+	}))
+	mux.Handle("/pipelines/asdflkj/cancel", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*canceled = true
+	}))
 	return httptest.NewServer(mux)
 }
 
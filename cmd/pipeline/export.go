@@ -0,0 +1,191 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+	"gopkg.in/yaml.v2"
+)
+
+type ExportOptions struct {
+	*pipelineOptions
+	application string
+	outputDir   string
+	format      string
+	keepIds     bool
+}
+
+var (
+	exportPipelineShort = "Write each pipeline of an application to its own file"
+	exportPipelineLong  = `Write each pipeline of an application to a file named after the pipeline,
+without the keys Spinnaker manages. Ids and indexes are dropped unless --keep-ids
+is set, so the files can be applied to any Spinnaker with 'spin pipeline apply'.`
+	exportPipelineExample = `  spin pipeline export -a app -o pipelines/app
+  spin pipeline export -a app -o pipelines/app --format yaml`
+)
+
+var unsafeFileNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// exportedPipeline records where a pipeline was exported to.
+type exportedPipeline struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+}
+
+func NewExportCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := ExportOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   exportPipelineShort,
+		Long:    exportPipelineLong,
+		Example: exportPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportPipelines(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application to export the pipelines of")
+	cmd.PersistentFlags().StringVarP(&options.outputDir, "output-dir", "o", "", "directory to write the pipeline files to, created if needed")
+	cmd.PersistentFlags().StringVar(&options.format, "format", "json", "file format: 'json' or 'yaml'")
+	cmd.PersistentFlags().BoolVar(&options.keepIds, "keep-ids", false, "keep the pipeline ids and indexes")
+
+	return cmd
+}
+
+func exportPipelines(cmd *cobra.Command, options ExportOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	if options.application == "" || options.outputDir == "" {
+		return util.NewUsageError("one of required parameters 'application' or 'output-dir' not set")
+	}
+	if options.format != "json" && options.format != "yaml" {
+		return util.NewUsageError("--format must be 'json' or 'yaml', got %q", options.format)
+	}
+
+	pipelines, err := spinClient.ListPipelines(cmd.Context(), options.application)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(options.outputDir, 0755); err != nil {
+		return err
+	}
+
+	exported := []exportedPipeline{}
+	used := map[string]bool{}
+	for _, p := range pipelines {
+		pipeline, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pipeline, err = client.NormalizePipeline(pipeline)
+		if err != nil {
+			return err
+		}
+		if !options.keepIds {
+			delete(pipeline, "id")
+			delete(pipeline, "index")
+		}
+
+		name := fmt.Sprintf("%v", pipeline["name"])
+		file := filepath.Join(options.outputDir, exportFileName(name, options.format, used))
+		content, err := marshalPipeline(pipeline, options.format)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, content, 0644); err != nil {
+			return err
+		}
+		exported = append(exported, exportedPipeline{Name: name, File: file})
+	}
+
+	if gateClient.UI.OutputFormat.Json || gateClient.UI.OutputFormat.JsonPath != "" {
+		if err := gateClient.UI.JsonOutput(exported); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range exported {
+			gateClient.UI.Output(e.File)
+		}
+	}
+	gateClient.UI.Success(fmt.Sprintf("Exported %d pipelines from application %s to %s", len(exported), options.application, options.outputDir))
+	return nil
+}
+
+// exportFileName names a pipeline's file after the pipeline, replacing
+// characters that are unsafe in file names and numbering names already used.
+func exportFileName(name, format string, used map[string]bool) string {
+	base := unsafeFileNameRe.ReplaceAllString(name, "-")
+	if base == "" || base == "." || base == ".." {
+		base = "pipeline"
+	}
+	fileName := base + "." + format
+	for i := 2; used[fileName]; i++ {
+		fileName = fmt.Sprintf("%s-%d.%s", base, i, format)
+	}
+	used[fileName] = true
+	return fileName
+}
+
+func marshalPipeline(pipeline map[string]interface{}, format string) ([]byte, error) {
+	if format == "yaml" {
+		return yaml.Marshal(wholeNumbers(pipeline))
+	}
+	content, err := json.MarshalIndent(pipeline, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// wholeNumbers returns a copy of a decoded JSON value with whole float64
+// numbers converted to int64, so YAML writes 3600000 rather than 3.6e+06.
+func wholeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := map[string]interface{}{}
+		for key, child := range v {
+			converted[key] = wholeNumbers(child)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, child := range v {
+			converted[i] = wholeNumbers(child)
+		}
+		return converted
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	}
+	return value
+}
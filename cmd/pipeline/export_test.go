@@ -0,0 +1,108 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPipelineExport_basic(t *testing.T) {
+	ts := testGatePipelineExportServer()
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	runPipelineExport(t, ts, filepath.Join(dir, "app"))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "app", "Deploy-to-prod.json"))
+	if err != nil {
+		t.Fatalf("Expected a file named after the pipeline: %v", err)
+	}
+	expected := "{\n  \"application\": \"app\",\n  \"name\": \"Deploy to prod\",\n  \"stages\": []\n}\n"
+	if string(content) != expected {
+		t.Fatalf("Expected a normalized pipeline:\n%s\ngot:\n%s", expected, content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app", "Deploy-to-prod-2.json")); err != nil {
+		t.Fatalf("Expected the clashing file name to be numbered: %v", err)
+	}
+}
+
+func TestPipelineExport_yaml(t *testing.T) {
+	ts := testGatePipelineExportServer()
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	runPipelineExport(t, ts, dir, "--format", "yaml", "--keep-ids")
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "Deploy-to-prod.yaml"))
+	if err != nil {
+		t.Fatalf("Expected a YAML file: %v", err)
+	}
+	if !strings.Contains(string(content), "name: Deploy to prod\n") || !strings.Contains(string(content), "id: p1\n") {
+		t.Fatalf("Expected YAML with the pipeline id, got:\n%s", content)
+	}
+	if strings.Contains(string(content), "updateTs") {
+		t.Fatalf("Expected server managed keys to be dropped, got:\n%s", content)
+	}
+
+	content, err = ioutil.ReadFile(filepath.Join(dir, "Deploy-to-prod-2.yaml"))
+	if err != nil {
+		t.Fatalf("Expected a YAML file: %v", err)
+	}
+	for _, expected := range []string{"stageTimeoutMs: 3600000\n", "waitTime: 12345678\n", "ratio: 0.5\n"} {
+		if !strings.Contains(string(content), expected) {
+			t.Fatalf("Expected numbers to keep their digits, missing %q in:\n%s", expected, content)
+		}
+	}
+}
+
+func runPipelineExport(t *testing.T, ts *httptest.Server, dir string, extraArgs ...string) {
+	args := append([]string{"pipeline", "export", "--application", "app", "--output-dir", dir, "--gate-endpoint", ts.URL}, extraArgs...)
+	currentCmd := NewExportCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+}
+
+// testGatePipelineExportServer lists two pipelines whose names make the same file name.
+func testGatePipelineExportServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[
+  {"id": "p1", "index": 0, "application": "app", "name": "Deploy to prod", "stages": [], "updateTs": "1", "lastModifiedBy": "alice"},
+  {"id": "p2", "index": 1, "application": "app", "name": "Deploy/to prod",
+   "stages": [{"refId": "1", "type": "wait", "waitTime": 12345678, "stageTimeoutMs": 3600000, "ratio": 0.5}]}
+]`)
+	}))
+}
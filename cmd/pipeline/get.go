@@ -15,11 +15,8 @@
 package pipeline
 
 import (
-	"errors"
-	"fmt"
-	"net/http"
-
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
 )
@@ -29,13 +26,27 @@ type GetOptions struct {
 	output      string
 	application string
 	name        string
+	id          string
+	showId      bool
 }
 
 var (
-	getPipelineShort   = "Get the pipeline with the provided name from the provided application"
-	getPipelineLong    = "Get the specified pipeline"
+	getPipelineShort = "Get the pipeline with the provided name from the provided application, or with the provided id"
+	getPipelineLong  = `Get the specified pipeline, by application and name or by id. Ids are
+how triggers and executions (as their pipelineConfigId) refer to pipelines.
+With --show-id, only the pipeline's id is printed.`
+	getPipelineExample = `  spin pipeline get -a app -n deploy
+  spin pipeline get --id 3f1c6d2e-8a7b-4c1e-9d3f-5b2a1e0c9f84
+  spin pipeline get -a app -n deploy --show-id`
 )
 
+// pipelineId identifies a pipeline, for 'pipeline get --show-id'.
+type pipelineId struct {
+	Id          string `json:"id"`
+	Application string `json:"application"`
+	Name        string `json:"name"`
+}
+
 func NewGetCmd(pipelineOptions pipelineOptions) *cobra.Command {
 	options := GetOptions{
 		pipelineOptions: &pipelineOptions,
@@ -44,6 +55,7 @@ func NewGetCmd(pipelineOptions pipelineOptions) *cobra.Command {
 		Use:     "get",
 		Short:   getPipelineShort,
 		Long:    getPipelineLong,
+		Example: getPipelineExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return getPipeline(cmd, options)
 		},
@@ -51,35 +63,48 @@ func NewGetCmd(pipelineOptions pipelineOptions) *cobra.Command {
 
 	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to")
 	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline")
+	cmd.PersistentFlags().StringVar(&options.id, "id", "", "id of the pipeline, searched for in the application if set or else in every application")
+	cmd.PersistentFlags().BoolVar(&options.showId, "show-id", false, "print only the pipeline's id")
 
 	return cmd
 }
 
 func getPipeline(cmd *cobra.Command, options GetOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
-	if options.application == "" || options.name == "" {
-		return errors.New("one of required parameters 'application' or 'name' not set")
+	var pipeline map[string]interface{}
+	switch {
+	case options.id != "" && options.name != "":
+		return util.NewUsageError("only one of 'id' or 'name' may be set")
+	case options.id != "":
+		pipeline, err = spinClient.GetPipelineById(cmd.Context(), options.application, options.id)
+	case options.application == "" || options.name == "":
+		return util.NewUsageError("one of required parameters 'application' or 'name' not set")
+	default:
+		pipeline, err = spinClient.GetPipeline(cmd.Context(), options.application, options.name)
 	}
-
-	successPayload, resp, err := gateClient.ApplicationControllerApi.GetPipelineConfigUsingGET(gateClient.Context,
-		options.application,
-		options.name)
-
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error getting pipeline in pipeline %s with name %s, status code: %d\n",
-			options.application,
-			options.name,
-			resp.StatusCode)
+	if options.showId {
+		ui := gateClient.UI
+		id, _ := pipeline["id"].(string)
+		application, _ := pipeline["application"].(string)
+		name, _ := pipeline["name"].(string)
+		if ui.OutputFormat.Json || ui.OutputFormat.JsonPath != "" {
+			return ui.JsonOutput(pipelineId{
+				Id:          id,
+				Application: application,
+				Name:        name,
+			})
+		}
+		ui.Output(id)
+		return nil
 	}
-
-	util.UI.JsonOutput(successPayload, util.UI.OutputFormat)
-	return nil
+	return gateClient.UI.JsonOutput(pipeline)
 }
@@ -15,6 +15,7 @@
 package pipeline
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -33,8 +34,16 @@ func getRootCmdForTest() *cobra.Command {
 	rootCmd.PersistentFlags().Bool("insecure", false, "Ignore Certificate Errors")
 	rootCmd.PersistentFlags().Bool("quiet", false, "Squelch non-essential output")
 	rootCmd.PersistentFlags().Bool("no-color", false, "Disable color")
+	rootCmd.PersistentFlags().String("color", "auto", "When to color output")
 	rootCmd.PersistentFlags().String("output", "", "Configure output formatting")
-	util.InitUI(false, false, "")
+	rootCmd.PersistentFlags().String("proxy-url", "", "HTTP(S) proxy to reach Gate through")
+	rootCmd.PersistentFlags().String("no-proxy", "", "Hosts that bypass the proxy")
+	rootCmd.PersistentFlags().StringArray("header", []string{}, "Header to add to every Gate request")
+	rootCmd.PersistentFlags().Duration("request-timeout", 0, "Timeout for each request to Gate")
+	rootCmd.PersistentFlags().Int("max-retries", 0, "Number of times to retry transient Gate failures")
+	rootCmd.PersistentFlags().Count("verbose", "Log Gate requests to stderr")
+	rootCmd.PersistentFlags().String("trace-file", "", "Write Gate requests to an HTTP Archive file")
+	rootCmd.PersistentFlags().String("run-as", "", "Service account to trigger pipelines as")
 	return rootCmd
 }
 
@@ -71,6 +80,9 @@ func TestPipelineGet_flags(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Command failed with: %s", err)
 	}
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d", util.ExitUsage, code)
+	}
 
 }
 
@@ -109,6 +121,9 @@ func TestPipelineGet_fail(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Command failed with: %s", err)
 	}
+	if code := util.ExitCode(err); code != util.ExitServer {
+		t.Fatalf("Expected exit code %d, got %d", util.ExitServer, code)
+	}
 }
 
 func TestPipelineGet_notfound(t *testing.T) {
@@ -127,6 +142,162 @@ func TestPipelineGet_notfound(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Command failed with: %s", err)
 	}
+	if code := util.ExitCode(err); code != util.ExitNotFound {
+		t.Fatalf("Expected exit code %d, got %d", util.ExitNotFound, code)
+	}
+}
+
+func TestPipelineGet_output(t *testing.T) {
+	ts := testGatePipelineGetSuccess()
+	defer ts.Close()
+
+	args := []string{"pipeline", "get", "--application", "app", "--name", "one", "--config", "/nonexistent", "--output", "jsonpath={.name}", "--gate-endpoint", ts.URL}
+	currentCmd := NewGetCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out, errOut bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out.String() != "\"one\"\n" {
+		t.Fatalf("Expected only the pipeline name on stdout, got %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "Could not read configuration file") {
+		t.Fatalf("Expected diagnostics on stderr, got %q", errOut.String())
+	}
+}
+
+func TestPipelineGet_badOutput(t *testing.T) {
+	ts := testGatePipelineGetSuccess()
+	defer ts.Close()
+
+	args := []string{"pipeline", "get", "--application", "app", "--name", "one", "--output", "yaml", "--gate-endpoint", ts.URL}
+	currentCmd := NewGetCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+}
+
+func TestPipelineGet_badColor(t *testing.T) {
+	ts := testGatePipelineGetSuccess()
+	defer ts.Close()
+
+	args := []string{"pipeline", "get", "--application", "app", "--name", "one", "--color", "sometimes", "--gate-endpoint", ts.URL}
+	currentCmd := NewGetCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+}
+
+const pipelineConfigsJson = `[
+  {"id": "p1", "application": "app", "name": "one"},
+  {"id": "p2", "application": "app", "name": "two"},
+  {"id": "p3", "application": "other", "name": "one"}
+]`
+
+func TestPipelineGet_id(t *testing.T) {
+	ts := testGatePipelineConfigsServer()
+	defer ts.Close()
+
+	out, err := runPipelineGetForId("--id", "p3", "--output", "jsonpath={.application}", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out != "\"other\"\n" {
+		t.Fatalf("Expected the pipeline in application other, got %q", out)
+	}
+}
+
+func TestPipelineGet_idInApplication(t *testing.T) {
+	ts := testGatePipelineConfigsServer()
+	defer ts.Close()
+
+	out, err := runPipelineGetForId("-a", "app", "--id", "p2", "--output", "jsonpath={.name}", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out != "\"two\"\n" {
+		t.Fatalf("Expected pipeline two, got %q", out)
+	}
+
+	_, err = runPipelineGetForId("-a", "app", "--id", "p3", "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitNotFound {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitNotFound, code, err)
+	}
+}
+
+func TestPipelineGet_showId(t *testing.T) {
+	ts := testGatePipelineGetSuccess()
+	defer ts.Close()
+
+	out, err := runPipelineGetForId("-a", "app", "-n", "one", "--show-id", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out != "pipeline_one\n" {
+		t.Fatalf("Expected the pipeline id, got %q", out)
+	}
+}
+
+func TestPipelineGet_idAndName(t *testing.T) {
+	ts := testGatePipelineConfigsServer()
+	defer ts.Close()
+
+	_, err := runPipelineGetForId("-a", "app", "-n", "one", "--id", "p1", "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+}
+
+func runPipelineGetForId(extraArgs ...string) (string, error) {
+	args := append([]string{"pipeline", "get"}, extraArgs...)
+	currentCmd := NewGetCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// testGatePipelineConfigsServer lists the pipelines of every application,
+// and of application app.
+func testGatePipelineConfigsServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/pipelineConfigs", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, pipelineConfigsJson)
+	}))
+	mux.Handle("/applications/app/pipelineConfigs", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[{"id": "p1", "application": "app", "name": "one"}, {"id": "p2", "application": "app", "name": "two"}]`)
+	}))
+	return httptest.NewServer(mux)
 }
 
 // testGatePipelineGetSuccess spins up a local http server that we will configure the GateClient
@@ -0,0 +1,122 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/graph"
+	"github.com/spinnaker/spin/util"
+)
+
+type GraphOptions struct {
+	*pipelineOptions
+	pipelineFile string
+	application  string
+	name         string
+	execution    string
+	format       string
+}
+
+var (
+	graphPipelineShort = "Draw the stage graph of a pipeline"
+	graphPipelineLong  = `Draw the stage graph of a pipeline file, a saved pipeline or an execution,
+with execution statuses. The graph is drawn as an ASCII tree, Graphviz DOT or a
+Mermaid flowchart.`
+	graphPipelineExample = `  spin pipeline graph --file pipeline.json
+  spin pipeline graph -a app -n deploy --format dot | dot -Tsvg > deploy.svg
+  spin pipeline graph --execution 01F2... --format mermaid`
+)
+
+func NewGraphCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := GraphOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "graph",
+		Short:   graphPipelineShort,
+		Long:    graphPipelineLong,
+		Example: graphPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return graphPipeline(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.pipelineFile, "file", "f", "", "path to a pipeline file")
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application of a saved pipeline")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of a saved pipeline")
+	cmd.PersistentFlags().StringVar(&options.execution, "execution", "", "id of a pipeline execution")
+	cmd.PersistentFlags().StringVar(&options.format, "format", "ascii", "graph format: 'ascii', 'dot' or 'mermaid'")
+
+	return cmd
+}
+
+func graphPipeline(cmd *cobra.Command, options GraphOptions) error {
+	if options.format != "ascii" && options.format != "dot" && options.format != "mermaid" {
+		return util.NewUsageError("--format must be 'ascii', 'dot' or 'mermaid', got %q", options.format)
+	}
+
+	var pipeline map[string]interface{}
+	var ui *util.ColorizeUi
+	var err error
+	switch {
+	case options.pipelineFile != "" && options.name == "" && options.execution == "":
+		if ui, err = gateclient.NewUI(cmd); err != nil {
+			return err
+		}
+		if pipeline, err = util.ParseJsonFromFileOrStdin(options.pipelineFile); err != nil {
+			return err
+		}
+	case options.pipelineFile == "" && options.name != "" && options.execution == "":
+		if options.application == "" {
+			return util.NewUsageError("--name requires --application")
+		}
+		gateClient, err := gateclient.NewGateClient(cmd)
+		if err != nil {
+			return err
+		}
+		ui = gateClient.UI
+		if pipeline, err = client.NewFromGateClient(gateClient).GetPipeline(cmd.Context(), options.application, options.name); err != nil {
+			return err
+		}
+	case options.pipelineFile == "" && options.name == "" && options.execution != "":
+		gateClient, err := gateclient.NewGateClient(cmd)
+		if err != nil {
+			return err
+		}
+		ui = gateClient.UI
+		if pipeline, err = client.NewFromGateClient(gateClient).GetExecution(cmd.Context(), options.execution); err != nil {
+			return err
+		}
+	default:
+		return util.NewUsageError("set exactly one of --file, --name or --execution")
+	}
+
+	g := graph.New(pipeline)
+	var rendered string
+	switch options.format {
+	case "dot":
+		rendered = graph.DOT(g)
+	case "mermaid":
+		rendered = graph.Mermaid(g)
+	default:
+		rendered = graph.ASCII(g, ui.OutputStatus)
+	}
+	ui.Output(strings.TrimSuffix(rendered, "\n"))
+	return nil
+}
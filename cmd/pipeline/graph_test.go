@@ -0,0 +1,89 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestPipelineGraph_file(t *testing.T) {
+	tempFile := tempPipelineFile(testPipelineJsonStr)
+	if tempFile == nil {
+		t.Fatal("Could not create temp pipeline file.")
+	}
+	defer os.Remove(tempFile.Name())
+
+	out, err := runPipelineGraph("--file", tempFile.Name())
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out != "[1] Wait (wait)\n" {
+		t.Fatalf("Expected a single stage, got:\n%s", out)
+	}
+}
+
+func TestPipelineGraph_execution(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pipelines/exec-1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"id": "exec-1", "name": "deploy", "stages": [
+  {"refId": "1", "type": "wait", "status": "SUCCEEDED"},
+  {"refId": "2", "type": "wait", "status": "RUNNING", "requisiteStageRefIds": ["1"]}
+]}`)
+	}))
+	defer ts.Close()
+
+	out, err := runPipelineGraph("--execution", "exec-1", "--format", "mermaid", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	expected := "graph LR\n  s0[\"wait<br/>SUCCEEDED\"]\n  s1[\"wait<br/>RUNNING\"]\n  s0 --> s1\n  style s0 fill:#b7e4b4\n  style s1 fill:#a8d8f0\n"
+	if out != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestPipelineGraph_flags(t *testing.T) {
+	_, err := runPipelineGraph("--file", "pipeline.json", "--execution", "exec-1")
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+}
+
+func runPipelineGraph(extraArgs ...string) (string, error) {
+	args := append([]string{"pipeline", "graph"}, extraArgs...)
+	currentCmd := NewGraphCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
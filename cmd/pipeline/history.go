@@ -0,0 +1,156 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+type HistoryOptions struct {
+	*pipelineOptions
+	application string
+	name        string
+	limit       int
+	revision    int
+	diff        bool
+}
+
+var (
+	historyPipelineShort = "List the saved revisions of a pipeline"
+	historyPipelineLong  = `List the saved revisions of a pipeline, newest first. Revision 1 is the
+current pipeline, 2 the one before it, and so on.
+
+With --revision, print that revision, or with --diff the changes made since it.`
+	historyPipelineExample = `  spin pipeline history -a app -n deploy
+  spin pipeline history -a app -n deploy --revision 3 --diff`
+)
+
+// pipelineRevision summarizes a revision in history listings.
+type pipelineRevision struct {
+	Revision       int         `json:"revision"`
+	UpdateTs       interface{} `json:"updateTs"`
+	LastModifiedBy interface{} `json:"lastModifiedBy"`
+}
+
+func NewHistoryCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := HistoryOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   historyPipelineShort,
+		Long:    historyPipelineLong,
+		Example: historyPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pipelineHistory(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline")
+	cmd.PersistentFlags().IntVar(&options.limit, "limit", client.DefaultHistoryLimit, "maximum number of revisions to list")
+	cmd.PersistentFlags().IntVar(&options.revision, "revision", 0, "print this revision instead of listing revisions")
+	cmd.PersistentFlags().BoolVar(&options.diff, "diff", false, "with --revision, show the changes made since the revision")
+
+	return cmd
+}
+
+func pipelineHistory(cmd *cobra.Command, options HistoryOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	if options.application == "" || options.name == "" {
+		return util.NewUsageError("one of required parameters 'application' or 'name' not set")
+	}
+	if options.diff && options.revision == 0 {
+		return util.NewUsageError("--diff requires --revision")
+	}
+
+	if options.revision != 0 {
+		revision, err := spinClient.GetPipelineRevision(cmd.Context(), options.application, options.name, options.revision)
+		if err != nil {
+			return err
+		}
+		if !options.diff {
+			return gateClient.UI.JsonOutput(revision)
+		}
+		current, err := spinClient.GetPipeline(cmd.Context(), options.application, options.name)
+		if err != nil {
+			return err
+		}
+		from, to, err := client.ComparablePipelines(revision, current)
+		if err != nil {
+			return err
+		}
+		diff, err := util.JsonDiff(from, to, fmt.Sprintf("revision %d", options.revision), "current")
+		if err != nil {
+			return err
+		}
+		gateClient.UI.OutputDiff(diff)
+		return nil
+	}
+
+	history, err := spinClient.GetPipelineHistory(cmd.Context(), options.application, options.name, options.limit)
+	if err != nil {
+		return err
+	}
+	revisions := []pipelineRevision{}
+	rows := [][]string{}
+	for i, h := range history {
+		revisions = append(revisions, pipelineRevision{Revision: i + 1, UpdateTs: h["updateTs"], LastModifiedBy: h["lastModifiedBy"]})
+		rows = append(rows, []string{strconv.Itoa(i + 1), formatTimestamp(h["updateTs"]), fmt.Sprintf("%v", valueOr(h["lastModifiedBy"], "-"))})
+	}
+	if gateClient.UI.OutputFormat.Json || gateClient.UI.OutputFormat.JsonPath != "" {
+		return gateClient.UI.JsonOutput(revisions)
+	}
+	gateClient.UI.Table([]string{"REVISION", "UPDATED", "AUTHOR"}, rows)
+	return nil
+}
+
+// formatTimestamp renders a Front50 epoch milliseconds timestamp, which may be
+// a number or a string, in local time.
+func formatTimestamp(ts interface{}) string {
+	var ms int64
+	switch v := ts.(type) {
+	case float64:
+		ms = int64(v)
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return v
+		}
+		ms = parsed
+	default:
+		return "-"
+	}
+	return time.UnixMilli(ms).Local().Format("2006-01-02 15:04:05 MST")
+}
+
+func valueOr(v interface{}, fallback interface{}) interface{} {
+	if v == nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestPipelineHistory_list(t *testing.T) {
+	ts := testGatePipelineHistoryServer(nil)
+	defer ts.Close()
+
+	out, err := runPipelineHistory(t, ts)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "REVISION") {
+		t.Fatalf("Expected a header and three revisions, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[3], "3 ") || !strings.HasSuffix(lines[3], "alice") {
+		t.Fatalf("Expected the oldest revision last, got:\n%s", out)
+	}
+}
+
+func TestPipelineHistory_revisionDiff(t *testing.T) {
+	ts := testGatePipelineHistoryServer(nil)
+	defer ts.Close()
+
+	out, err := runPipelineHistory(t, ts, "--revision", "3", "--diff")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	for _, expected := range []string{"--- revision 3", "+++ current", `-      "waitTime": 10`, `+      "waitTime": 30`} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected diff to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestPipelineHistory_revisionMissing(t *testing.T) {
+	ts := testGatePipelineHistoryServer(nil)
+	defer ts.Close()
+
+	_, err := runPipelineHistory(t, ts, "--revision", "4")
+	if code := util.ExitCode(err); code != util.ExitNotFound {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitNotFound, code, err)
+	}
+}
+
+func runPipelineHistory(t *testing.T, ts *httptest.Server, extraArgs ...string) (string, error) {
+	args := append([]string{"pipeline", "history", "--application", "app", "--name", "deploy", "--gate-endpoint", ts.URL}, extraArgs...)
+	currentCmd := NewHistoryCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// testGatePipelineHistoryServer serves pipeline deploy with three revisions,
+// waiting 30, 20 and 10 seconds from newest to oldest. Updates are decoded
+// into updated when it is not nil.
+func testGatePipelineHistoryServer(updated *map[string]interface{}) *httptest.Server {
+	revision := func(waitTime int, ts int64, author string) string {
+		return fmt.Sprintf(`{"id": "p1", "index": 1, "application": "app", "name": "deploy", "stages": [{"refId": "1", "type": "wait", "waitTime": %d}], "updateTs": "%d", "lastModifiedBy": %q}`,
+			waitTime, ts, author)
+	}
+	current := revision(30, 1526578883109, "carol")
+	mux := http.NewServeMux()
+	mux.Handle("/applications/app/pipelineConfigs/deploy", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, current)
+	}))
+	mux.Handle("/pipelineConfigs/p1/history", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[%s, %s, %s]\n", current, revision(20, 1526478883109, "bob"), revision(10, 1526378883109, "alice"))
+	}))
+	mux.Handle("/pipelines/p1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || updated == nil {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(updated)
+		fmt.Fprintln(w, "{}")
+	}))
+	return httptest.NewServer(mux)
+}
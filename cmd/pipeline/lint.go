@@ -0,0 +1,157 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/lint"
+	"github.com/spinnaker/spin/util"
+)
+
+type LintOptions struct {
+	*pipelineOptions
+	pipelineFile string
+	disable      []string
+	strict       bool
+}
+
+var (
+	lintPipelineShort = "Check a pipeline file for mistakes without saving it"
+	lintPipelineLong  = `Check a pipeline file offline: required keys, the stage graph (unique refIds,
+requisite stages that exist, no cycles), stage and trigger types, parameters and
+expected artifact references.
+
+Exits with status 3 if any errors are found, or with --strict, any warnings.`
+	lintPipelineExample = `  spin pipeline lint --file pipeline.json
+  spin pipeline lint --file pipeline.json --disable stage-types --output json`
+)
+
+func NewLintCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := LintOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "lint",
+		Short:   lintPipelineShort,
+		Long:    lintPipelineLong,
+		Example: lintPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return lintPipeline(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.pipelineFile, "file", "f", "", "path to the pipeline file (default stdin)")
+	cmd.PersistentFlags().StringSliceVar(&options.disable, "disable", []string{}, "rules to skip, from: "+strings.Join(lintRuleNames(), ", "))
+	cmd.PersistentFlags().BoolVar(&options.strict, "strict", false, "fail on warnings as well as errors")
+
+	return cmd
+}
+
+func lintPipeline(cmd *cobra.Command, options LintOptions) error {
+	ui, err := gateclient.NewUI(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLintRules(options.disable); err != nil {
+		return err
+	}
+
+	pipeline, err := util.ParseJsonFromFileOrStdin(options.pipelineFile)
+	if err != nil {
+		return err
+	}
+	findings := lint.Lint(pipeline, options.disable...)
+
+	if ui.OutputFormat.Json || ui.OutputFormat.JsonPath != "" {
+		if err := ui.JsonOutput(findings); err != nil {
+			return err
+		}
+	} else {
+		for _, finding := range findings {
+			ui.Output(fmt.Sprintf("%-7s %s (%s)", finding.Severity, finding, finding.Rule))
+		}
+	}
+
+	errors := len(lint.Errors(findings))
+	warnings := len(findings) - errors
+	if errors > 0 || (options.strict && warnings > 0) {
+		return util.NewValidationError("Pipeline has %d errors and %d warnings", errors, warnings)
+	}
+	if warnings > 0 {
+		ui.Success(fmt.Sprintf("Pipeline has no errors and %d warnings", warnings))
+	} else {
+		ui.Success("Pipeline has no problems")
+	}
+	return nil
+}
+
+// lintRuleOptions holds the flags for skipping lint rules when a pipeline is
+// validated before it is saved.
+type lintRuleOptions struct {
+	disable []string
+	skip    bool
+}
+
+func (o *lintRuleOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&o.disable, "disable", []string{}, "lint rules to skip before saving, from: "+strings.Join(lintRuleNames(), ", "))
+	flags.BoolVar(&o.skip, "skip-lint", false, "skip every lint rule but required-keys before saving")
+}
+
+// disabledRules returns the names of the lint rules to skip. The pipeline
+// can't be saved without a name and application, so --skip-lint keeps
+// required-keys.
+func (o lintRuleOptions) disabledRules() ([]string, error) {
+	if o.skip {
+		disabled := []string{}
+		for _, name := range lintRuleNames() {
+			if name != "required-keys" {
+				disabled = append(disabled, name)
+			}
+		}
+		return disabled, nil
+	}
+	if err := checkLintRules(o.disable); err != nil {
+		return nil, err
+	}
+	return o.disable, nil
+}
+
+func lintRuleNames() []string {
+	names := []string{}
+	for _, rule := range lint.Rules() {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+// checkLintRules fails with a usage error if any of the names is not a lint rule.
+func checkLintRules(names []string) error {
+	known := map[string]bool{}
+	for _, name := range lintRuleNames() {
+		known[name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return util.NewUsageError("unknown lint rule %s", name)
+		}
+	}
+	return nil
+}
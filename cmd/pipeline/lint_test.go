@@ -0,0 +1,110 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+const lintFindingsPipelineJsonStr = `
+{
+  "application": "app",
+  "name": "broken",
+  "stages": [
+    {"refId": "1", "type": "wait", "requisiteStageRefIds": ["2"]},
+    {"refId": "2", "type": "myWebhook"}
+  ],
+  "triggers": [{"type": "cron"}]
+}
+`
+
+func TestPipelineLint_errors(t *testing.T) {
+	out, err := runPipelineLint(t, lintFindingsPipelineJsonStr)
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+	expected := "warning stages[1].type: myWebhook is not a built-in stage type; check it is a custom or plugin stage (stage-types)\n" +
+		"error   triggers[0].cronExpression: cron trigger requires cronExpression (triggers)\n"
+	if out != expected {
+		t.Fatalf("Expected findings:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestPipelineLint_json(t *testing.T) {
+	out, err := runPipelineLint(t, testPipelineJsonStr, "--output", "json", "--strict")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	var findings []interface{}
+	if err := json.Unmarshal([]byte(out), &findings); err != nil || len(findings) != 0 {
+		t.Fatalf("Expected an empty JSON list of findings, got %q", out)
+	}
+}
+
+func TestPipelineSave_lintError(t *testing.T) {
+	ts := GateServerSuccess()
+	defer ts.Close()
+
+	tempFile := tempPipelineFile(lintFindingsPipelineJsonStr)
+	if tempFile == nil {
+		t.Fatal("Could not create temp pipeline file.")
+	}
+	defer os.Remove(tempFile.Name())
+
+	args := []string{"pipeline", "save", "--file", tempFile.Name(), "--gate-endpoint", ts.URL}
+	currentCmd := NewSaveCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+	if !strings.Contains(err.Error(), "cron trigger requires cronExpression") {
+		t.Fatalf("Expected the lint error, got: %v", err)
+	}
+}
+
+func runPipelineLint(t *testing.T, pipeline string, extraArgs ...string) (string, error) {
+	tempFile := tempPipelineFile(pipeline)
+	if tempFile == nil {
+		t.Fatal("Could not create temp pipeline file.")
+	}
+	defer os.Remove(tempFile.Name())
+
+	args := append([]string{"pipeline", "lint", "--file", tempFile.Name()}, extraArgs...)
+	currentCmd := NewLintCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
@@ -15,11 +15,8 @@
 package pipeline
 
 import (
-	"errors"
-	"fmt"
-	"net/http"
-
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
 	"github.com/spinnaker/spin/util"
 )
@@ -55,27 +52,20 @@ func NewListCmd(pipelineOptions pipelineOptions) *cobra.Command {
 }
 
 func listPipeline(cmd *cobra.Command, options ListOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	gateClient, err := gateclient.NewGateClient(cmd)
 	if err != nil {
 		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
 	if options.application == "" {
-		return errors.New("required parameter 'application' not set")
+		return util.NewUsageError("required parameter 'application' not set")
 	}
 
-	successPayload, resp, err := gateClient.ApplicationControllerApi.GetPipelineConfigsForApplicationUsingGET(gateClient.Context, options.application)
-
+	pipelines, err := spinClient.ListPipelines(cmd.Context(), options.application)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error listing pipelines for application %s, status code: %d\n",
-			options.application,
-			resp.StatusCode)
-	}
-
-	util.UI.JsonOutput(successPayload, util.UI.OutputFormat)
-	return nil
+	return gateClient.UI.JsonOutput(pipelines)
 }
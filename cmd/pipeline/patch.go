@@ -0,0 +1,168 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+type PatchOptions struct {
+	*pipelineOptions
+	application string
+	name        string
+	patchFiles  []string
+	patchType   string
+	baseFile    string
+	dryRun      bool
+	lint        lintRuleOptions
+}
+
+var (
+	patchPipelineShort = "Apply JSON patches or overlays to a pipeline"
+	patchPipelineLong  = `Apply patches, in order, to the deployed pipeline and save the result. Patch
+files are YAML or JSON, and their type is set with --type:
+
+  json     RFC 6902 JSON Patch, a list of operations; the default for lists
+  merge    RFC 7386 JSON Merge Patch; the default for objects
+  overlay  a merge patch that merges the stages and other lists of objects by
+           refId, name or id instead of replacing them; an object with
+           "$patch": "delete" removes the object it matches
+
+With --base, the patches are applied to the base file instead of the deployed
+pipeline, keeping the deployed pipeline's id, application and name. The
+changes are shown as a diff; --dry-run shows them without saving.
+
+The patched pipeline is checked with the rules of 'spin pipeline lint' before
+it is saved; skip rules with --disable, or all of them with --skip-lint.`
+	patchPipelineExample = `  spin pipeline patch -a app -n deploy --patch disable-notifications.json
+  spin pipeline patch -a app -n deploy-prod --base base.json --patch prod.yml --type overlay --dry-run`
+)
+
+func NewPatchCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := PatchOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "patch",
+		Short:   patchPipelineShort,
+		Long:    patchPipelineLong,
+		Example: patchPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return patchPipeline(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline")
+	cmd.PersistentFlags().StringArrayVarP(&options.patchFiles, "patch", "p", []string{}, "patch file to apply; repeat to apply several in order")
+	cmd.PersistentFlags().StringVar(&options.patchType, "type", "", "patch type: 'json', 'merge' or 'overlay' (default depends on each patch)")
+	cmd.PersistentFlags().StringVar(&options.baseFile, "base", "", "pipeline file to patch instead of the deployed pipeline")
+	cmd.PersistentFlags().BoolVar(&options.dryRun, "dry-run", false, "show the changes without saving them")
+	options.lint.addFlags(cmd.PersistentFlags())
+
+	return cmd
+}
+
+func patchPipeline(cmd *cobra.Command, options PatchOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+	ui := gateClient.UI
+
+	if options.application == "" || options.name == "" || len(options.patchFiles) == 0 {
+		return util.NewUsageError("one of required parameters 'application', 'name' or 'patch' not set")
+	}
+	switch options.patchType {
+	case "", client.PatchJson, client.PatchMerge, client.PatchOverlay:
+	default:
+		return util.NewUsageError("--type must be '%s', '%s' or '%s', got %q", client.PatchJson, client.PatchMerge, client.PatchOverlay, options.patchType)
+	}
+
+	patches := []client.PipelinePatch{}
+	for _, file := range options.patchFiles {
+		patch, err := readPatch(file, options.patchType)
+		if err != nil {
+			return err
+		}
+		patches = append(patches, patch)
+	}
+	disabledRules, err := options.lint.disabledRules()
+	if err != nil {
+		return err
+	}
+	patchOptions := client.PatchOptions{DryRun: options.dryRun, DisabledLintRules: disabledRules}
+	if options.baseFile != "" {
+		patchOptions.Base, err = util.ParseJsonFromFileOrStdin(options.baseFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := spinClient.PatchPipeline(cmd.Context(), options.application, options.name, patches, patchOptions)
+	if err != nil {
+		return err
+	}
+	if !options.dryRun {
+		return reportSaveResult(ui, result, "patched")
+	}
+
+	if ui.OutputFormat.Json || ui.OutputFormat.JsonPath != "" {
+		return ui.JsonOutput(result.Pipeline)
+	}
+	if result.Action == client.PipelineUnchanged {
+		ui.Success(fmt.Sprintf("Pipeline %s unchanged", result.Name))
+		return nil
+	}
+	diff, err := pipelineDiff(result.Previous, result.Pipeline, "current", "patched")
+	if err != nil {
+		return err
+	}
+	ui.OutputDiff(diff)
+	ui.Success(fmt.Sprintf("Dry run, pipeline %s not saved", result.Name))
+	return nil
+}
+
+// readPatch reads a YAML or JSON patch file. Without a patch type, a list is
+// taken to be a JSON Patch and anything else a merge patch.
+func readPatch(file, patchType string) (client.PipelinePatch, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return client.PipelinePatch{}, err
+	}
+	document, err := util.ParseYamlOrJson(content)
+	if err != nil {
+		return client.PipelinePatch{}, util.NewValidationError("Could not parse patch %s: %v", file, err)
+	}
+	if patchType == "" {
+		patchType = client.PatchMerge
+		if _, ok := document.([]interface{}); ok {
+			patchType = client.PatchJson
+		}
+	}
+	patch, err := json.Marshal(document)
+	if err != nil {
+		return client.PipelinePatch{}, err
+	}
+	return client.PipelinePatch{Type: patchType, Patch: patch}, nil
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+const patchPipelineJson = `{
+  "id": "p1",
+  "application": "app",
+  "name": "deploy",
+  "stages": [
+    {"id": "generated", "refId": "1", "type": "wait", "waitTime": 30},
+    {"refId": "2", "type": "manualJudgment", "requisiteStageRefIds": ["1"]}
+  ]
+}`
+
+func TestPipelinePatch_jsonPatch(t *testing.T) {
+	var updated map[string]interface{}
+	ts := testGatePipelinePatchServer(&updated)
+	defer ts.Close()
+	patchFile := tempPipelineFile(`[{"op": "replace", "path": "/stages/0/waitTime", "value": 60}]`)
+	defer os.Remove(patchFile.Name())
+
+	_, errOut, err := runPipelinePatch("--patch", patchFile.Name(), "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	stage := updated["stages"].([]interface{})[0].(map[string]interface{})
+	if updated["id"] != "p1" || stage["waitTime"] != 60.0 {
+		t.Fatalf("Expected p1 to be updated with waitTime 60, got %v", updated)
+	}
+	for _, expected := range []string{`-      "waitTime": 30`, `+      "waitTime": 60`, "Pipeline deploy updated (id p1)"} {
+		if !strings.Contains(errOut, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, errOut)
+		}
+	}
+}
+
+func TestPipelinePatch_overlayDryRun(t *testing.T) {
+	var updated map[string]interface{}
+	ts := testGatePipelinePatchServer(&updated)
+	defer ts.Close()
+	patchFile := tempPipelineFile("stages:\n- refId: \"2\"\n  $patch: delete\n- refId: \"1\"\n  waitTime: 10\n")
+	defer os.Remove(patchFile.Name())
+
+	out, _, err := runPipelinePatch("--patch", patchFile.Name(), "--type", "overlay", "--dry-run", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if updated != nil {
+		t.Fatalf("Expected nothing saved on a dry run, got %v", updated)
+	}
+	for _, expected := range []string{`+      "waitTime": 10`, `-      "type": "manualJudgment"`} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestPipelinePatch_base(t *testing.T) {
+	var updated map[string]interface{}
+	ts := testGatePipelinePatchServer(&updated)
+	defer ts.Close()
+	baseFile := tempPipelineFile(`{"application": "base", "name": "base", "stages": [{"refId": "1", "type": "wait", "waitTime": 5}]}`)
+	defer os.Remove(baseFile.Name())
+	patchFile := tempPipelineFile(`{"keepWaitingPipelines": true}`)
+	defer os.Remove(patchFile.Name())
+
+	_, _, err := runPipelinePatch("--base", baseFile.Name(), "--patch", patchFile.Name(), "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if updated["id"] != "p1" || updated["application"] != "app" || updated["name"] != "deploy" || updated["keepWaitingPipelines"] != true {
+		t.Fatalf("Expected the patched base to replace deploy, got %v", updated)
+	}
+	if len(updated["stages"].([]interface{})) != 1 {
+		t.Fatalf("Expected the base's stages, got %v", updated["stages"])
+	}
+}
+
+func TestPipelinePatch_dryRunMatchesReport(t *testing.T) {
+	var updated map[string]interface{}
+	ts := testGatePipelinePatchServer(&updated)
+	defer ts.Close()
+	baseFile := tempPipelineFile(`{"stages": [{"refId": "1", "type": "wait", "waitTime": 5}]}`)
+	defer os.Remove(baseFile.Name())
+	patchFile := tempPipelineFile(`{}`)
+	defer os.Remove(patchFile.Name())
+
+	preview, _, err := runPipelinePatch("--base", baseFile.Name(), "--patch", patchFile.Name(), "--dry-run", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	_, report, err := runPipelinePatch("--base", baseFile.Name(), "--patch", patchFile.Name(), "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	for _, out := range []string{preview, report} {
+		if !strings.Contains(out, `+      "waitTime": 5`) || strings.Contains(out, "generated") {
+			t.Errorf("Expected the diff to ignore the generated stage id, got:\n%s", out)
+		}
+	}
+}
+
+func TestPipelinePatch_rename(t *testing.T) {
+	var updated map[string]interface{}
+	ts := testGatePipelinePatchServer(&updated)
+	defer ts.Close()
+	patchFile := tempPipelineFile(`{"name": "other"}`)
+	defer os.Remove(patchFile.Name())
+
+	_, _, err := runPipelinePatch("--patch", patchFile.Name(), "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+	if updated != nil {
+		t.Fatalf("Expected nothing saved, got %v", updated)
+	}
+}
+
+func runPipelinePatch(extraArgs ...string) (string, string, error) {
+	args := append([]string{"pipeline", "patch", "-a", "app", "-n", "deploy"}, extraArgs...)
+	currentCmd := NewPatchCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out, errOut bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), errOut.String(), err
+}
+
+// testGatePipelinePatchServer serves pipeline deploy of application app,
+// decoding updates to it into updated.
+func testGatePipelinePatchServer(updated *map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/applications/app/pipelineConfigs/deploy", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, patchPipelineJson)
+	}))
+	mux.Handle("/pipelines/p1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			json.NewDecoder(r.Body).Decode(updated)
+		}
+		fmt.Fprintln(w, "{}")
+	}))
+	return httptest.NewServer(mux)
+}
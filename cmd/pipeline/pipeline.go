@@ -29,5 +29,18 @@ func NewPipelineCmd(out io.Writer) *cobra.Command {
 	cmd.AddCommand(NewDeleteCmd(options))
 	cmd.AddCommand(NewSaveCmd(options))
 	cmd.AddCommand(NewExecuteCmd(options))
+	cmd.AddCommand(NewDiffCmd(options))
+	cmd.AddCommand(NewHistoryCmd(options))
+	cmd.AddCommand(NewRollbackCmd(options))
+	cmd.AddCommand(NewRenameCmd(options))
+	cmd.AddCommand(NewEnableCmd(options))
+	cmd.AddCommand(NewDisableCmd(options))
+	cmd.AddCommand(NewApplyCmd(options))
+	cmd.AddCommand(NewExportCmd(options))
+	cmd.AddCommand(NewLintCmd(options))
+	cmd.AddCommand(NewGraphCmd(options))
+	cmd.AddCommand(NewCopyCmd(options))
+	cmd.AddCommand(NewSearchCmd(options))
+	cmd.AddCommand(NewPatchCmd(options))
 	return cmd
 }
@@ -0,0 +1,80 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+type RenameOptions struct {
+	*pipelineOptions
+	application string
+	from        string
+	to          string
+}
+
+var (
+	renamePipelineShort   = "Rename a pipeline"
+	renamePipelineLong    = "Rename a pipeline, keeping its id, config and execution history"
+	renamePipelineExample = "  spin pipeline rename -a app --from deploy --to deploy-prod"
+)
+
+func NewRenameCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := RenameOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "rename",
+		Short:   renamePipelineShort,
+		Long:    renamePipelineLong,
+		Example: renamePipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return renamePipeline(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to")
+	cmd.PersistentFlags().StringVar(&options.from, "from", "", "current name of the pipeline")
+	cmd.PersistentFlags().StringVar(&options.to, "to", "", "new name of the pipeline")
+
+	return cmd
+}
+
+func renamePipeline(cmd *cobra.Command, options RenameOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	if options.application == "" || options.from == "" || options.to == "" {
+		return util.NewUsageError("one of required parameters 'application', 'from' or 'to' not set")
+	}
+	if options.from == options.to {
+		return util.NewUsageError("'from' and 'to' are both %s", options.from)
+	}
+
+	if err := spinClient.RenamePipeline(cmd.Context(), options.application, options.from, options.to); err != nil {
+		return err
+	}
+
+	gateClient.UI.Success(fmt.Sprintf("Pipeline %s renamed to %s", options.from, options.to))
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestPipelineRename_basic(t *testing.T) {
+	var moved map[string]interface{}
+	ts := testGatePipelineRenameServer(&moved, false)
+	defer ts.Close()
+
+	err := runPipelineRename(ts, "deploy", "deploy-prod")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if moved["application"] != "app" || moved["from"] != "deploy" || moved["to"] != "deploy-prod" {
+		t.Fatalf("Expected a rename of app/deploy to deploy-prod, got %v", moved)
+	}
+}
+
+func TestPipelineRename_exists(t *testing.T) {
+	var moved map[string]interface{}
+	ts := testGatePipelineRenameServer(&moved, true)
+	defer ts.Close()
+
+	err := runPipelineRename(ts, "deploy", "deploy-prod")
+	if code := util.ExitCode(err); code != util.ExitConflict {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitConflict, code, err)
+	}
+	if moved != nil {
+		t.Fatalf("Expected no rename, got %v", moved)
+	}
+}
+
+func TestPipelineRename_missing(t *testing.T) {
+	ts := testGatePipelineGetMissing()
+	defer ts.Close()
+
+	err := runPipelineRename(ts, "deploy", "deploy-prod")
+	if code := util.ExitCode(err); code != util.ExitNotFound {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitNotFound, code, err)
+	}
+}
+
+func runPipelineRename(ts *httptest.Server, from, to string) error {
+	args := []string{"pipeline", "rename", "--application", "app", "--from", from, "--to", to, "--gate-endpoint", ts.URL}
+	currentCmd := NewRenameCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// testGatePipelineRenameServer serves pipeline deploy, and deploy-prod if
+// targetExists. Rename requests are decoded into moved.
+func testGatePipelineRenameServer(moved *map[string]interface{}, targetExists bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/applications/app/pipelineConfigs/deploy", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"id": "p1", "application": "app", "name": "deploy"}`)
+	}))
+	mux.Handle("/applications/app/pipelineConfigs/deploy-prod", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !targetExists {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"id": "p2", "application": "app", "name": "deploy-prod"}`)
+	}))
+	mux.Handle("/pipelines/move", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(moved)
+	}))
+	return httptest.NewServer(mux)
+}
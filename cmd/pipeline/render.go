@@ -0,0 +1,77 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/spinnaker/spin/util"
+)
+
+// renderOptions holds the flags for rendering a pipeline file with values
+// before it is used.
+type renderOptions struct {
+	template   string
+	valueFiles []string
+	settings   []string
+	strict     bool
+}
+
+func (o *renderOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.template, "template", "", "render the file with 'vars' (${NAME} references) or 'go' (text/template); 'vars' by default when values are given")
+	flags.StringArrayVar(&o.valueFiles, "values", []string{}, "YAML or JSON file of values to render the file with; later files take precedence")
+	flags.StringArrayVar(&o.settings, "set", []string{}, "value to render the file with, as key=value, taking precedence over --values")
+	flags.BoolVar(&o.strict, "strict", false, "fail on variables that have no value")
+}
+
+// readPipeline reads the pipeline file, or stdin if path is empty, and
+// renders it with the values.
+func (o renderOptions) readPipeline(path string) (map[string]interface{}, error) {
+	engine := o.template
+	if engine == "" && (len(o.valueFiles) > 0 || len(o.settings) > 0) {
+		engine = util.TemplateVars
+	}
+	if engine != "" && engine != util.TemplateVars && engine != util.TemplateGo {
+		return nil, util.NewUsageError("--template must be '%s' or '%s', got %q", util.TemplateVars, util.TemplateGo, engine)
+	}
+
+	content, err := util.ReadFileOrStdin(path)
+	if err != nil {
+		return nil, err
+	}
+	if engine == "" {
+		return util.ParseJson(content)
+	}
+	values, err := util.LoadValues(o.valueFiles, o.settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if engine == util.TemplateGo {
+		rendered, err := util.RenderGoTemplate(content, values, o.strict)
+		if err != nil {
+			return nil, err
+		}
+		return util.ParseJson(rendered)
+	}
+	pipeline, err := util.ParseJson(content)
+	if err != nil {
+		return nil, err
+	}
+	substituted, err := util.SubstituteVars(pipeline, values, o.strict)
+	if err != nil {
+		return nil, err
+	}
+	return substituted.(map[string]interface{}), nil
+}
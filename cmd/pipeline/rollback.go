@@ -0,0 +1,80 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/util"
+)
+
+type RollbackOptions struct {
+	*pipelineOptions
+	application string
+	name        string
+	revision    int
+}
+
+var (
+	rollbackPipelineShort = "Restore a previous revision of a pipeline"
+	rollbackPipelineLong  = `Restore a previous revision of a pipeline, as numbered by 'spin pipeline history'.
+The changes are shown as a diff before the pipeline is reported as updated.`
+	rollbackPipelineExample = "  spin pipeline rollback -a app -n deploy --revision 2"
+)
+
+func NewRollbackCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := RollbackOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "rollback",
+		Short:   rollbackPipelineShort,
+		Long:    rollbackPipelineLong,
+		Example: rollbackPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rollbackPipeline(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "Spinnaker application the pipeline belongs to")
+	cmd.PersistentFlags().StringVarP(&options.name, "name", "n", "", "name of the pipeline")
+	cmd.PersistentFlags().IntVar(&options.revision, "revision", 0, "revision to restore, where 1 is the current pipeline")
+
+	return cmd
+}
+
+func rollbackPipeline(cmd *cobra.Command, options RollbackOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	if options.application == "" || options.name == "" {
+		return util.NewUsageError("one of required parameters 'application' or 'name' not set")
+	}
+	if options.revision == 0 {
+		return util.NewUsageError("required parameter 'revision' not set")
+	}
+
+	result, err := spinClient.RollbackPipeline(cmd.Context(), options.application, options.name, options.revision)
+	if err != nil {
+		return err
+	}
+	return reportSaveResult(gateClient.UI, result, fmt.Sprintf("revision %d", options.revision))
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPipelineRollback_basic(t *testing.T) {
+	var updated map[string]interface{}
+	ts := testGatePipelineHistoryServer(&updated)
+	defer ts.Close()
+
+	args := []string{"pipeline", "rollback", "--application", "app", "--name", "deploy", "--revision", "2", "--gate-endpoint", ts.URL}
+	currentCmd := NewRollbackCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var errOut bytes.Buffer
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	stages, _ := updated["stages"].([]interface{})
+	if len(stages) != 1 || stages[0].(map[string]interface{})["waitTime"] != float64(20) || updated["id"] != "p1" {
+		t.Fatalf("Expected revision 2 to be saved as pipeline p1, got %v", updated)
+	}
+	if _, exists := updated["updateTs"]; exists {
+		t.Fatalf("Expected updateTs to be left to Front50, got %v", updated)
+	}
+	for _, expected := range []string{`+      "waitTime": 20`, "Pipeline deploy updated (id p1)"} {
+		if !strings.Contains(errOut.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, errOut.String())
+		}
+	}
+}
+
+func TestPipelineRollback_current(t *testing.T) {
+	ts := testGatePipelineHistoryServer(nil)
+	defer ts.Close()
+
+	args := []string{"pipeline", "rollback", "--application", "app", "--name", "deploy", "--revision", "1", "--gate-endpoint", ts.URL}
+	currentCmd := NewRollbackCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var errOut bytes.Buffer
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if !strings.Contains(errOut.String(), "Pipeline deploy unchanged") {
+		t.Fatalf("Expected the pipeline to be unchanged, got:\n%s", errOut.String())
+	}
+}
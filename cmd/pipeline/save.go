@@ -16,11 +16,11 @@ package pipeline
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
 	"github.com/spinnaker/spin/cmd/gateclient"
-
+	"github.com/spinnaker/spin/lint"
 	"github.com/spinnaker/spin/util"
 )
 
@@ -28,11 +28,32 @@ type SaveOptions struct {
 	*pipelineOptions
 	output       string
 	pipelineFile string
+	render       renderOptions
+	renderOnly   bool
+	lint         lintRuleOptions
 }
 
 var (
-	savePipelineShort   = "Save the provided pipeline"
-	savePipelineLong    = "Save the provided pipeline"
+	savePipelineShort = "Save the provided pipeline"
+	savePipelineLong  = `Save the provided pipeline, updating the existing pipeline with the same name. The changes are shown as a diff, and the pipeline is reported as created, updated or unchanged.
+
+The file can be rendered with values from --values files and --set first.
+With '--template vars', the default when values are given, ${NAME} in the
+pipeline's strings is replaced by the value NAME and $${NAME} escapes a
+reference. SpEL expressions such as ${ parameters.env } are left alone, but
+SpEL that is a bare name, such as ${trigger} or ${execution}, is taken for a
+reference, replaced by a value of that name and failing --strict; escape it
+as $${trigger} to keep it. With
+'--template go', the file is a Go text/template. --render-only prints the
+rendered pipeline without saving it.
+
+The pipeline is checked with the rules of 'spin pipeline lint' first, and not
+saved if any report an error. Skip rules with --disable, or all of them with
+--skip-lint, to save pipelines Spinnaker accepts but the rules don't, such as
+legacy pipelines whose stages have no refId.`
+	savePipelineExample = `  spin pipeline save -f pipeline.json
+  spin pipeline save -f pipeline.json --values prod.yml --set region=us-east1 --strict
+  spin pipeline save -f pipeline.json.tmpl --template go --values prod.yml --render-only`
 )
 
 func NewSaveCmd(pipelineOptions pipelineOptions) *cobra.Command {
@@ -44,59 +65,92 @@ func NewSaveCmd(pipelineOptions pipelineOptions) *cobra.Command {
 		Aliases: []string{},
 		Short:   savePipelineShort,
 		Long:    savePipelineLong,
+		Example: savePipelineExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return savePipeline(cmd, options)
 		},
 	}
 
 	cmd.PersistentFlags().StringVarP(&options.pipelineFile, "file", "f", "", "path to the pipeline file")
+	options.render.addFlags(cmd.PersistentFlags())
+	cmd.PersistentFlags().BoolVar(&options.renderOnly, "render-only", false, "print the rendered pipeline instead of saving it")
+	options.lint.addFlags(cmd.PersistentFlags())
 
 	return cmd
 }
 
 func savePipeline(cmd *cobra.Command, options SaveOptions) error {
-	gateClient, err := gateclient.NewGateClient(cmd.InheritedFlags())
+	disabledRules, err := options.lint.disabledRules()
 	if err != nil {
 		return err
 	}
-
-	pipelineJson, err := util.ParseJsonFromFileOrStdin(options.pipelineFile)
+	pipelineJson, err := options.render.readPipeline(options.pipelineFile)
 	if err != nil {
 		return err
 	}
-	valid := true
-	if _, exists := pipelineJson["name"]; !exists {
-		util.UI.Error("Required pipeline key 'name' missing...\n")
-		valid = false
+	if options.renderOnly {
+		ui, err := gateclient.NewUI(cmd)
+		if err != nil {
+			return err
+		}
+		return ui.JsonOutput(pipelineJson)
 	}
 
-	if _, exists := pipelineJson["application"]; !exists {
-		util.UI.Error("Required pipeline key 'application' missing...\n")
-		valid = false
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
 	}
+	spinClient := client.NewFromGateClient(gateClient)
 
-	if template, exists := pipelineJson["template"]; exists && len(template.(map[string]interface{})) > 0 {
-		if _, exists := pipelineJson["schema"]; !exists {
-			util.UI.Error("Required pipeline key 'schema' missing for templated pipeline...\n")
-			valid = false
+	for _, finding := range lint.Lint(pipelineJson, disabledRules...) {
+		if finding.Severity == lint.SeverityWarning {
+			gateClient.UI.Warn(fmt.Sprintf("Warning: %s", finding))
 		}
-		pipelineJson["type"] = "templatedPipeline"
 	}
-
-	if !valid {
-		return fmt.Errorf("Submitted pipeline is invalid: %s\n", pipelineJson)
+	result, err := spinClient.SavePipeline(cmd.Context(), pipelineJson, disabledRules...)
+	if err != nil {
+		return err
 	}
 
-	resp, err := gateClient.PipelineControllerApi.SavePipelineUsingPOST(gateClient.Context, pipelineJson)
+	return reportSaveResult(gateClient.UI, result, "submitted")
+}
 
+// reportSaveResult shows the diff of an updated pipeline, where the new
+// version is labelled toName, then reports what was saved.
+func reportSaveResult(ui *util.ColorizeUi, result *client.SavePipelineResult, toName string) error {
+	if result.Action == client.PipelineUpdated {
+		if err := showPipelineDiff(ui, result.Previous, result.Pipeline, "current", toName); err != nil {
+			return err
+		}
+	}
+	if ui.OutputFormat.Json || ui.OutputFormat.JsonPath != "" {
+		return ui.JsonOutput(result)
+	}
+	message := fmt.Sprintf("Pipeline %s %s", result.Name, result.Action)
+	if result.Id != "" {
+		message = fmt.Sprintf("%s (id %s)", message, result.Id)
+	}
+	ui.Success(message)
+	return nil
+}
+
+// showPipelineDiff writes the changes from the deployed pipeline to the one
+// being saved.
+func showPipelineDiff(ui *util.ColorizeUi, from, to map[string]interface{}, fromName, toName string) error {
+	diff, err := pipelineDiff(from, to, fromName, toName)
 	if err != nil {
 		return err
 	}
+	ui.Diff(diff)
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error saving pipeline, status code: %d\n", resp.StatusCode)
+// pipelineDiff returns the changes from the deployed pipeline to the one
+// being saved, compared the same way the save decides whether anything changed.
+func pipelineDiff(from, to map[string]interface{}, fromName, toName string) (string, error) {
+	comparableTo, comparableFrom, err := client.ComparablePipelines(to, from)
+	if err != nil {
+		return "", err
 	}
-
-	util.UI.Info(util.Colorize().Color(fmt.Sprintf("[reset][bold][green]Pipeline save succeeded")))
-	return nil
+	return util.JsonDiff(comparableFrom, comparableTo, fromName, toName)
 }
@@ -15,12 +15,17 @@
 package pipeline
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/spinnaker/spin/util"
 )
 
 func TestPipelineSave_basic(t *testing.T) {
@@ -191,6 +196,204 @@ func TestPipelineSave_missingapp(t *testing.T) {
 	}
 }
 
+func TestPipelineSave_update(t *testing.T) {
+	existing := strings.Replace(testPipelineJsonStr, `"waitTime": 30`, `"waitTime": 60`, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprintln(w, existing)
+		}
+	}))
+	defer ts.Close()
+
+	tempFile := tempPipelineFile(testPipelineJsonStr)
+	if tempFile == nil {
+		t.Fatal("Could not create temp pipeline file.")
+	}
+	defer os.Remove(tempFile.Name())
+
+	args := []string{"pipeline", "save", "--file", tempFile.Name(), "--gate-endpoint", ts.URL}
+	currentCmd := NewSaveCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var errOut bytes.Buffer
+	rootCmd.SetErr(&errOut)
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	for _, expected := range []string{`-      "waitTime": 60`, `+      "waitTime": 30`, "Pipeline pipeline1 updated (id pipeline1)"} {
+		if !strings.Contains(errOut.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, errOut.String())
+		}
+	}
+}
+
+const templatedPipelineJson = `{
+  "application": "${app}",
+  "name": "deploy-${env}",
+  "stages": [
+    {"refId": "1", "type": "wait", "name": "Wait in ${region}", "waitTime": "${waitTime}", "comments": "${ parameters.note } $${env}"}
+  ]
+}`
+
+func TestPipelineSave_renderVars(t *testing.T) {
+	pipelineFile := tempPipelineFile(templatedPipelineJson)
+	defer os.Remove(pipelineFile.Name())
+	valuesFile := tempPipelineFile("app: web\nenv: staging\nregion: us-east1\nwaitTime: 30\n")
+	defer os.Remove(valuesFile.Name())
+
+	out, err := runPipelineSaveRender("--file", pipelineFile.Name(), "--values", valuesFile.Name(), "--set", "env=prod", "--render-only")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	for _, expected := range []string{
+		`"application": "web"`,
+		`"name": "deploy-prod"`,
+		`"name": "Wait in us-east1"`,
+		`"waitTime": 30`,
+		`"comments": "${ parameters.note } ${env}"`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %s, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestPipelineSave_renderStrict(t *testing.T) {
+	pipelineFile := tempPipelineFile(templatedPipelineJson)
+	defer os.Remove(pipelineFile.Name())
+
+	out, err := runPipelineSaveRender("--file", pipelineFile.Name(), "--set", "app=web", "--render-only")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if !strings.Contains(out, `"name": "deploy-${env}"`) {
+		t.Fatalf("Expected undefined variables to be kept, got:\n%s", out)
+	}
+
+	_, err = runPipelineSaveRender("--file", pipelineFile.Name(), "--set", "app=web", "--strict", "--render-only")
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "env, region, waitTime") {
+		t.Fatalf("Expected the undefined variables to be named, got %v", err)
+	}
+}
+
+func TestPipelineSave_renderGo(t *testing.T) {
+	pipelineFile := tempPipelineFile(`{
+  "application": "{{ .app }}",
+  "name": "deploy",
+  "stages": [{{ range $i, $region := .regions }}{{ if $i }}, {{ end }}{"refId": "{{ $i }}", "type": "wait", "name": "{{ $region }}"}{{ end }}],
+  "notifications": {{ .notifications | default "[]" }}
+}`)
+	defer os.Remove(pipelineFile.Name())
+	valuesFile := tempPipelineFile("app: web\nregions: [east, west]\n")
+	defer os.Remove(valuesFile.Name())
+
+	out, err := runPipelineSaveRender("--file", pipelineFile.Name(), "--template", "go", "--values", valuesFile.Name(), "--render-only", "--output", "jsonpath={.stages[1].name}")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out != "\"west\"\n" {
+		t.Fatalf("Expected the second stage to be west, got %q", out)
+	}
+
+	_, err = runPipelineSaveRender("--file", pipelineFile.Name(), "--template", "go", "--set", "app=web", "--strict", "--render-only")
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitInvalid, code, err)
+	}
+}
+
+func TestPipelineSave_renderGoMissing(t *testing.T) {
+	pipelineFile := tempPipelineFile(`{"application": "web", "name": "deploy{{ .suffix }}", "description": "prints <no value> for nil"}`)
+	defer os.Remove(pipelineFile.Name())
+
+	out, err := runPipelineSaveRender("--file", pipelineFile.Name(), "--template", "go", "--render-only")
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	for _, expected := range []string{`"name": "deploy"`, `"description": "prints \u003cno value\u003e for nil"`} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %s, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestPipelineSave_renderSaves(t *testing.T) {
+	var saved map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&saved)
+		}
+	}))
+	defer ts.Close()
+	pipelineFile := tempPipelineFile(templatedPipelineJson)
+	defer os.Remove(pipelineFile.Name())
+
+	_, err := runPipelineSaveRender("--file", pipelineFile.Name(), "--set", "app=web", "--set", "env=prod", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if saved["application"] != "web" || saved["name"] != "deploy-prod" {
+		t.Fatalf("Expected the rendered pipeline to be saved, got %v", saved)
+	}
+}
+
+func TestPipelineSave_lintRules(t *testing.T) {
+	var saved map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&saved)
+		}
+	}))
+	defer ts.Close()
+	// Legacy pipelines run their stages in order and have no refIds.
+	pipelineFile := tempPipelineFile(`{"application": "app", "name": "legacy", "stages": [{"type": "wait"}, {"type": "wait"}]}`)
+	defer os.Remove(pipelineFile.Name())
+
+	_, err := runPipelineSaveRender("--file", pipelineFile.Name(), "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitInvalid || saved != nil {
+		t.Fatalf("Expected exit code %d without saving, got %d: %v", util.ExitInvalid, code, err)
+	}
+	for _, flags := range [][]string{{"--disable", "stage-refs"}, {"--skip-lint"}} {
+		saved = nil
+		args := append([]string{"--file", pipelineFile.Name(), "--gate-endpoint", ts.URL}, flags...)
+		if _, err := runPipelineSaveRender(args...); err != nil {
+			t.Fatalf("Command with %v failed with: %s", flags, err)
+		}
+		if saved["name"] != "legacy" {
+			t.Fatalf("Expected the pipeline to be saved with %v, got %v", flags, saved)
+		}
+	}
+
+	_, err = runPipelineSaveRender("--file", pipelineFile.Name(), "--disable", "no-such-rule", "--gate-endpoint", ts.URL)
+	if code := util.ExitCode(err); code != util.ExitUsage {
+		t.Fatalf("Expected exit code %d, got %d: %v", util.ExitUsage, code, err)
+	}
+}
+
+func runPipelineSaveRender(extraArgs ...string) (string, error) {
+	args := append([]string{"pipeline", "save"}, extraArgs...)
+	currentCmd := NewSaveCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
 func tempPipelineFile(pipelineContent string) *os.File {
 	tempFile, _ := ioutil.TempFile("" /* /tmp dir. */, "pipeline-spec")
 	bytes, err := tempFile.Write([]byte(pipelineContent))
@@ -0,0 +1,103 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spinnaker/spin/client"
+	"github.com/spinnaker/spin/cmd/gateclient"
+)
+
+type SearchOptions struct {
+	*pipelineOptions
+	application  string
+	stageTypes   []string
+	accounts     []string
+	triggerTypes []string
+	contains     []string
+	where        []string
+}
+
+var (
+	searchPipelineShort = "Find pipelines across applications by stage, account, trigger or content"
+	searchPipelineLong  = `Find the pipelines, in every application or the one set with --application,
+that match all of the given predicates. A predicate given more than once
+matches pipelines satisfying any of its values. Matches are listed by
+application, name and id.`
+	searchPipelineExample = `  spin pipeline search --account prod-k8s
+  spin pipeline search --stage-type webhook --contains hooks.example.com
+  spin pipeline search --trigger-type cron -a app
+  spin pipeline search --where '{.stages[?(@.type=="deployManifest")].namespaceOverride}'`
+)
+
+func NewSearchCmd(pipelineOptions pipelineOptions) *cobra.Command {
+	options := SearchOptions{
+		pipelineOptions: &pipelineOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "search",
+		Short:   searchPipelineShort,
+		Long:    searchPipelineLong,
+		Example: searchPipelineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return searchPipelines(cmd, options)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.application, "application", "a", "", "only search the application's pipelines")
+	cmd.PersistentFlags().StringArrayVar(&options.stageTypes, "stage-type", []string{}, "match pipelines with a stage of the type")
+	cmd.PersistentFlags().StringArrayVar(&options.accounts, "account", []string{}, "match pipelines referencing the account")
+	cmd.PersistentFlags().StringArrayVar(&options.triggerTypes, "trigger-type", []string{}, "match pipelines with a trigger of the type")
+	cmd.PersistentFlags().StringArrayVar(&options.contains, "contains", []string{}, "match pipelines with a key or value containing the text, ignoring case")
+	cmd.PersistentFlags().StringArrayVar(&options.where, "where", []string{}, "match pipelines for which the jsonpath expression finds a value")
+
+	return cmd
+}
+
+func searchPipelines(cmd *cobra.Command, options SearchOptions) error {
+	gateClient, err := gateclient.NewGateClient(cmd)
+	if err != nil {
+		return err
+	}
+	spinClient := client.NewFromGateClient(gateClient)
+
+	matches, err := spinClient.SearchPipelines(cmd.Context(), client.PipelineQuery{
+		Application:  options.application,
+		StageTypes:   options.stageTypes,
+		Accounts:     options.accounts,
+		TriggerTypes: options.triggerTypes,
+		Contains:     options.contains,
+		JsonPaths:    options.where,
+	})
+	if err != nil {
+		return err
+	}
+
+	ui := gateClient.UI
+	if ui.OutputFormat.Json || ui.OutputFormat.JsonPath != "" {
+		return ui.JsonOutput(matches)
+	}
+	if len(matches) > 0 {
+		rows := [][]string{}
+		for _, m := range matches {
+			rows = append(rows, []string{m.Application, m.Name, m.Id})
+		}
+		ui.Table([]string{"APPLICATION", "NAME", "ID"}, rows)
+	}
+	ui.Info(fmt.Sprintf("%d pipelines matched", len(matches)))
+	return nil
+}
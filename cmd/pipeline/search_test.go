@@ -0,0 +1,99 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+const searchPipelinesJson = `[
+  {"id": "p1", "application": "web", "name": "deploy", "stages": [{"refId": "1", "type": "deployManifest", "account": "prod-k8s"}]},
+  {"id": "p2", "application": "api", "name": "deploy", "stages": [{"refId": "1", "type": "deployManifest", "account": "prod-k8s"}]},
+  {"id": "p3", "application": "api", "name": "test", "stages": [{"refId": "1", "type": "runJobManifest", "account": "test-k8s"}]}
+]`
+
+func TestPipelineSearch_basic(t *testing.T) {
+	ts := testGatePipelineSearchServer()
+	defer ts.Close()
+
+	out, err := runPipelineSearch("--account", "prod-k8s", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	expected := "APPLICATION   NAME     ID\napi           deploy   p2\nweb           deploy   p1\n"
+	if out != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestPipelineSearch_json(t *testing.T) {
+	ts := testGatePipelineSearchServer()
+	defer ts.Close()
+
+	out, err := runPipelineSearch("--stage-type", "runJobManifest", "--output", "json", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	expected := "[\n {\n  \"application\": \"api\",\n  \"name\": \"test\",\n  \"id\": \"p3\"\n }\n]\n"
+	if out != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, out)
+	}
+}
+
+func TestPipelineSearch_noMatches(t *testing.T) {
+	ts := testGatePipelineSearchServer()
+	defer ts.Close()
+
+	out, err := runPipelineSearch("--contains", "webhook", "--gate-endpoint", ts.URL)
+	if err != nil {
+		t.Fatalf("Command failed with: %s", err)
+	}
+	if out != "" {
+		t.Fatalf("Expected no output, got:\n%s", out)
+	}
+}
+
+func runPipelineSearch(extraArgs ...string) (string, error) {
+	args := append([]string{"pipeline", "search"}, extraArgs...)
+	currentCmd := NewSearchCmd(pipelineOptions{})
+	rootCmd := getRootCmdForTest()
+	rootCmd.SilenceUsage = true
+	pipelineCmd := NewPipelineCmd(os.Stdout)
+	pipelineCmd.AddCommand(currentCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+// testGatePipelineSearchServer lists the pipelines of every application.
+func testGatePipelineSearchServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pipelineConfigs" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, searchPipelinesJson)
+	}))
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spinnaker/spin/util"
+)
+
+// selectorRequirementRe splits a selector requirement into its field,
+// operator and value.
+var selectorRequirementRe = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+)\s*(=~|!=|==|=)(.*)$`)
+
+// selectorStartRe matches the field and operator that start a requirement.
+var selectorStartRe = regexp.MustCompile(`^\s*[A-Za-z0-9_.-]+\s*(=~|!=|==|=)`)
+
+// parseSelector parses a comma-separated list of requirements, each
+// 'field=value', 'field!=value' or 'field=~regex', into a predicate matching
+// pipelines that satisfy all of them. Nested fields are joined with dots.
+func parseSelector(selector string) (func(map[string]interface{}) bool, error) {
+	requirements := []func(map[string]interface{}) bool{}
+	for _, requirement := range splitSelector(selector) {
+		parts := selectorRequirementRe.FindStringSubmatch(requirement)
+		if parts == nil {
+			return nil, util.NewUsageError("invalid selector requirement %q, expected 'field=value', 'field!=value' or 'field=~regex'", requirement)
+		}
+		path := strings.Split(parts[1], ".")
+		value := strings.TrimSpace(parts[3])
+		switch parts[2] {
+		case "=~":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, util.NewUsageError("invalid selector regex %q: %v", value, err)
+			}
+			requirements = append(requirements, func(pipeline map[string]interface{}) bool {
+				field, ok := selectorField(pipeline, path)
+				return ok && re.MatchString(field)
+			})
+		case "!=":
+			requirements = append(requirements, func(pipeline map[string]interface{}) bool {
+				field, ok := selectorField(pipeline, path)
+				return !ok || field != value
+			})
+		default:
+			requirements = append(requirements, func(pipeline map[string]interface{}) bool {
+				field, ok := selectorField(pipeline, path)
+				return ok && field == value
+			})
+		}
+	}
+
+	return func(pipeline map[string]interface{}) bool {
+		for _, requirement := range requirements {
+			if !requirement(pipeline) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// selectorField returns the pipeline's field at path formatted as a string,
+// and whether it is set.
+func selectorField(pipeline map[string]interface{}, path []string) (string, bool) {
+	var value interface{} = pipeline
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		value, ok = object[key]
+		if !ok {
+			return "", false
+		}
+	}
+	if value == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%v", value), true
+}
+
+// splitSelector splits a selector on the commas that start a new
+// requirement, so values such as the regex ^deploy-(a|b){1,2}$ may
+// contain commas.
+func splitSelector(selector string) []string {
+	requirements := []string{}
+	start := 0
+	for i := 0; i < len(selector); i++ {
+		if selector[i] == ',' && selectorStartRe.MatchString(selector[i+1:]) {
+			requirements = append(requirements, selector[start:i])
+			start = i + 1
+		}
+	}
+	return append(requirements, selector[start:])
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"github.com/spinnaker/spin/cmd/pipeline-template"
 	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spinnaker/spin/cmd/application"
+	"github.com/spinnaker/spin/cmd/auth"
 	"github.com/spinnaker/spin/cmd/pipeline"
+	"github.com/spinnaker/spin/util"
 	"github.com/spinnaker/spin/version"
 )
 
@@ -15,13 +23,43 @@ type RootOptions struct {
 	GateEndpoint     string
 	ignoreCertErrors bool
 	quiet            bool
-	color            bool
+	noColor          bool
+	color            string
 	outputFormat     string
+	runAs            string
+	proxyUrl         string
+	noProxy          string
+	headers          []string
+	requestTimeout   time.Duration
+	maxRetries       int
+	verbosity        int
+	traceFile        string
 }
 
-func Execute(out io.Writer) error {
+// Execute runs the spin command line and returns the process exit code.
+// Errors are reported on stderr, as JSON when '--output json' is set.
+// SIGINT and SIGTERM cancel the context passed to every command, aborting
+// in-flight Gate requests; a second signal terminates spin immediately.
+func Execute(out io.Writer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	cmd := NewCmdRoot(out)
-	return cmd.Execute()
+	err := cmd.ExecuteContext(ctx)
+	if err == nil {
+		return util.ExitOK
+	}
+	if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		err = util.NewInterruptedError("Interrupted")
+	}
+
+	outputFormat, _ := cmd.PersistentFlags().GetString("output")
+	util.WriteError(cmd.ErrOrStderr(), err, outputFormat == "json")
+	return util.ExitCode(err)
 }
 
 func NewCmdRoot(out io.Writer) *cobra.Command {
@@ -32,16 +70,32 @@ func NewCmdRoot(out io.Writer) *cobra.Command {
 		SilenceErrors: true,
 		Version:      version.String(),
 	}
+	// Command results go to out; diagnostics go to stderr.
+	cmd.SetOut(out)
 
 	cmd.PersistentFlags().StringVar(&options.configFile, "config", "", "path to config file (default $HOME/.spin/config)")
 	cmd.PersistentFlags().StringVar(&options.GateEndpoint, "gate-endpoint", "", "Gate (API server) endpoint (default http://localhost:8084)")
 	cmd.PersistentFlags().BoolVarP(&options.ignoreCertErrors, "insecure", "k", false, "ignore certificate errors")
 	cmd.PersistentFlags().BoolVarP(&options.quiet, "quiet", "q", false, "squelch non-essential output")
-	cmd.PersistentFlags().BoolVar(&options.color, "no-color", true, "disable color")
-	cmd.PersistentFlags().StringVar(&options.outputFormat, "output", "", "configure output formatting")
+	cmd.PersistentFlags().BoolVar(&options.noColor, "no-color", false, "disable color (same as --color=never)")
+	cmd.PersistentFlags().StringVar(&options.color, "color", util.ColorAuto, "when to color output: 'auto' (on terminals, honoring $NO_COLOR and $CLICOLOR_FORCE), 'always' or 'never'")
+	cmd.PersistentFlags().StringVar(&options.outputFormat, "output", "", "configure output formatting: 'json', or 'jsonpath=<template>'")
+	cmd.PersistentFlags().StringVar(&options.proxyUrl, "proxy-url", "", "HTTP(S) proxy to reach Gate through (default from $HTTPS_PROXY/$HTTP_PROXY)")
+	cmd.PersistentFlags().StringVar(&options.noProxy, "no-proxy", "", "comma-separated hosts that bypass the proxy (default from $NO_PROXY)")
+	cmd.PersistentFlags().StringArrayVarP(&options.headers, "header", "H", []string{}, "header to add to every Gate request, as 'Name: value' (repeatable)")
+	cmd.PersistentFlags().DurationVar(&options.requestTimeout, "request-timeout", 0, "timeout for each attempt at a request to Gate, e.g. 30s (default from config, or 2m)")
+	cmd.PersistentFlags().IntVar(&options.maxRetries, "max-retries", 3, "number of times to retry transient Gate failures (0 disables retries)")
+	cmd.PersistentFlags().CountVarP(&options.verbosity, "verbose", "v", "log Gate requests to stderr; repeat for headers (-vv) and bodies (-vvv)")
+	cmd.PersistentFlags().StringVar(&options.traceFile, "trace-file", "", "write every Gate request and response to this HTTP Archive (.har) file")
+	cmd.PersistentFlags().StringVar(&options.runAs, "run-as", "", "service account to trigger pipelines as (see 'spin auth service-accounts')")
+
+	cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return util.NewUsageError("%v", err)
+	})
 
 	// create subcommands
 	cmd.AddCommand(application.NewApplicationCmd(out))
+	cmd.AddCommand(auth.NewAuthCmd(out))
 	cmd.AddCommand(pipeline.NewPipelineCmd(out))
 	cmd.AddCommand(pipeline_template.NewPipelineTemplateCmd(out))
 
@@ -14,28 +14,87 @@
 
 package x509
 
+import (
+	"errors"
+)
+
 // X509Config is the configuration for using X.509 certs to
 // authenticate with Spinnaker.
 type X509Config struct {
-	CertPath string `yaml:"certPath"`
-	KeyPath  string `yaml:"keyPath"`
-	Cert     string `yaml:"cert"` // Cert is base64 encoded PEM block.
-	Key      string `yaml:"key"`  // Key is base64 encoded PEM block.
+	CertPath   string `yaml:"certPath"`
+	KeyPath    string `yaml:"keyPath"`
+	Cert       string `yaml:"cert"`       // Cert is base64 encoded PEM block.
+	Key        string `yaml:"key"`        // Key is base64 encoded PEM block.
+	Pkcs12Path string `yaml:"pkcs12Path"` // Pkcs12Path is a .p12/.pfx bundle holding the key and certificate chain.
+	ChainPath  string `yaml:"chainPath"`  // ChainPath is a PEM file of intermediate certificates to present.
+
+	// Passphrase configures how to unlock an encrypted key or PKCS#12 bundle.
+	Passphrase *PassphraseConfig `yaml:"passphrase,omitempty"`
+}
+
+// PassphraseConfig describes where to source the passphrase for an encrypted
+// key. Exactly one source should be set.
+type PassphraseConfig struct {
+	Env     string `yaml:"env"`     // Env names an environment variable holding the passphrase.
+	Command string `yaml:"command"` // Command is run through the shell; its trimmed stdout is the passphrase.
+	Prompt  bool   `yaml:"prompt"`  // Prompt asks for the passphrase on the terminal.
+}
+
+func (p *PassphraseConfig) IsValid() error {
+	sources := 0
+	if p.Env != "" {
+		sources++
+	}
+	if p.Command != "" {
+		sources++
+	}
+	if p.Prompt {
+		sources++
+	}
+	if sources != 1 {
+		return errors.New("x509 passphrase must set exactly one of 'env', 'command' or 'prompt: true'")
+	}
+	return nil
 }
 
-func (x *X509Config) IsValid() bool {
-	// Only one pair of configs properties should be set.
+func (x *X509Config) IsValid() error {
+	// Only one group of configs properties should be set.
 	pathPropertySet := x.CertPath != "" || x.KeyPath != ""
 	pemPropertySet := x.Cert != "" || x.Key != ""
-	if pathPropertySet && pemPropertySet {
-		return false
+	pkcs12PropertySet := x.Pkcs12Path != ""
+
+	set := 0
+	for _, s := range []bool{pathPropertySet, pemPropertySet, pkcs12PropertySet} {
+		if s {
+			set++
+		}
+	}
+	if set == 0 {
+		return errors.New("Incorrect x509 auth configuration.\nMust specify certPath/keyPath, cert/key or pkcs12Path.")
+	}
+	if set > 1 {
+		return errors.New("Incorrect x509 auth configuration.\nOnly one of certPath/keyPath, cert/key or pkcs12Path may be specified.")
 	}
 
-	pathPropertyMismatch := (x.CertPath != "" && x.KeyPath == "") || (x.KeyPath != "" && x.CertPath == "")
-	pemPropertyMismatch := (x.Cert != "" && x.Key == "") || (x.Key != "" && x.Cert == "")
-	if pathPropertyMismatch || pemPropertyMismatch {
-		return false
+	if x.CertPath != "" && x.KeyPath == "" {
+		return errors.New("Incorrect x509 auth configuration.\n'certPath' is set but 'keyPath' is missing.")
+	}
+	if x.KeyPath != "" && x.CertPath == "" {
+		return errors.New("Incorrect x509 auth configuration.\n'keyPath' is set but 'certPath' is missing.")
+	}
+	if x.Cert != "" && x.Key == "" {
+		return errors.New("Incorrect x509 auth configuration.\n'cert' is set but 'key' is missing.")
+	}
+	if x.Key != "" && x.Cert == "" {
+		return errors.New("Incorrect x509 auth configuration.\n'key' is set but 'cert' is missing.")
+	}
+
+	if pkcs12PropertySet && x.ChainPath != "" {
+		return errors.New("Incorrect x509 auth configuration.\n'chainPath' cannot be combined with 'pkcs12Path'; the bundle already carries its chain.")
 	}
 
-	return true
+	if x.Passphrase != nil {
+		return x.Passphrase.IsValid()
+	}
+	return nil
 }
@@ -0,0 +1,52 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package x509
+
+import (
+	"testing"
+)
+
+func TestX509Config_IsValid(t *testing.T) {
+	valid := []X509Config{
+		{CertPath: "cert.pem", KeyPath: "key.pem"},
+		{Cert: "cert", Key: "key"},
+		{Pkcs12Path: "client.p12"},
+		{CertPath: "cert.pem", KeyPath: "key.pem", ChainPath: "chain.pem"},
+		{Pkcs12Path: "client.p12", Passphrase: &PassphraseConfig{Env: "P12_PASSWORD"}},
+		{CertPath: "cert.pem", KeyPath: "key.pem", Passphrase: &PassphraseConfig{Prompt: true}},
+	}
+	for _, c := range valid {
+		if err := c.IsValid(); err != nil {
+			t.Errorf("Expected %+v to be valid, got: %s", c, err)
+		}
+	}
+
+	invalid := []X509Config{
+		{},
+		{CertPath: "cert.pem"},
+		{KeyPath: "key.pem"},
+		{Cert: "cert"},
+		{CertPath: "cert.pem", KeyPath: "key.pem", Cert: "cert", Key: "key"},
+		{CertPath: "cert.pem", KeyPath: "key.pem", Pkcs12Path: "client.p12"},
+		{Pkcs12Path: "client.p12", ChainPath: "chain.pem"},
+		{Pkcs12Path: "client.p12", Passphrase: &PassphraseConfig{}},
+		{Pkcs12Path: "client.p12", Passphrase: &PassphraseConfig{Env: "P", Command: "pass show p12"}},
+	}
+	for _, c := range invalid {
+		if err := c.IsValid(); err == nil {
+			t.Errorf("Expected %+v to be invalid", c)
+		}
+	}
+}
@@ -15,13 +15,38 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spinnaker/spin/config/auth"
 )
 
 // Config is the CLI configuration kept in '~/.spin/config'.
 type Config struct {
-	Gate struct {
-		Endpoint string `yaml:"endpoint"`
-	} `yaml:"gate"`
+	Gate GateConfig       `yaml:"gate"`
+	Auth *auth.AuthConfig `yaml:"auth"`
+
+	// Contexts names other Spinnaker instances, selected with e.g.
+	// 'spin pipeline copy --to-context'.
+	Contexts map[string]Context `yaml:"contexts,omitempty"`
+}
+
+// Context describes how to reach and authenticate with one Spinnaker instance.
+type Context struct {
+	Gate GateConfig       `yaml:"gate"`
 	Auth *auth.AuthConfig `yaml:"auth"`
 }
+
+// GateConfig describes how to reach Gate.
+type GateConfig struct {
+	Endpoint string `yaml:"endpoint"`
+
+	// ProxyUrl is the HTTP(S) proxy used to reach Gate. Falls back to the
+	// HTTP_PROXY/HTTPS_PROXY environment variables when unset.
+	ProxyUrl string `yaml:"proxyUrl,omitempty"`
+	// NoProxy is a comma-separated list of hosts, domains and CIDRs that bypass the proxy.
+	NoProxy string `yaml:"noProxy,omitempty"`
+	// Headers are added to every request sent to Gate, e.g. for IAP or tenant routing.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Timeout bounds each attempt at a request to Gate, e.g. "30s".
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
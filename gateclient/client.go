@@ -0,0 +1,464 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package gateclient talks to Gate as configured by the spin config,
+// handling authentication, proxies, retries and request tracing.
+package gateclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	_ "net/http/pprof"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/spinnaker/spin/config"
+	gate "github.com/spinnaker/spin/gateapi"
+	"github.com/spinnaker/spin/util"
+	"github.com/spinnaker/spin/version"
+)
+
+// defaultTimeout bounds each attempt at a request to Gate when neither the
+// config nor flags set a timeout.
+const defaultTimeout = 2 * time.Minute
+
+// GatewayClient is the wrapper with authentication
+type GatewayClient struct {
+	// The exported fields below should be set by anyone using a command
+	// with an GatewayClient field. These are expected to be set externally
+	// (not from within the command itself).
+
+	// Generate Gate Api client.
+	*gate.APIClient
+
+	// Spin CLI configuration.
+	Config config.Config
+
+	// Context for OAuth2 access token.
+	Context context.Context
+
+	// UI receives results, warnings and prompts.
+	UI *util.ColorizeUi
+
+	// This is the set of flags global to the command parser.
+	gateEndpoint string
+
+	ignoreCertErrors bool
+
+	// Proxy, header and timeout overrides from flags, applied over Config.Gate.
+	proxyUrl       string
+	noProxy        string
+	headers        map[string]string
+	requestTimeout time.Duration
+
+	// Number of times transient failures are retried.
+	maxRetries int
+
+	// Request tracing level and HAR output file.
+	verbosity int
+	traceFile string
+
+	// Location of the spin config.
+	configLocation string
+
+	// The config as read from configLocation, before a context was applied.
+	fileConfig config.Config
+
+	// Raw Http Client to do OAuth2 login.
+	httpClient *http.Client
+}
+
+// Options configures a GatewayClient created with New. The zero value reads
+// $HOME/.spin/config and talks to the Gate endpoint it names.
+type Options struct {
+	// ConfigPath is the spin config file, $HOME/.spin/config by default.
+	ConfigPath string
+
+	// ContextName selects a context from the config, whose gate and auth
+	// sections are used in place of the top level ones.
+	ContextName string
+
+	// GateEndpoint overrides the endpoint from the config, which defaults to http://localhost:8084.
+	GateEndpoint string
+
+	// Insecure skips verification of Gate's certificate.
+	Insecure bool
+
+	// ProxyUrl, NoProxy, Headers and RequestTimeout override the gate
+	// section of the config.
+	ProxyUrl       string
+	NoProxy        string
+	Headers        map[string]string
+	RequestTimeout time.Duration
+
+	// MaxRetries is the number of times transient failures are retried.
+	MaxRetries int
+
+	// Verbosity and TraceFile enable request tracing, see VerboseRequests.
+	Verbosity int
+	TraceFile string
+
+	// UI receives results, warnings and prompts, such as for a key
+	// passphrase or an OAuth2 authorization code. Defaults to a UI on stdin,
+	// stdout and stderr, without color.
+	UI *util.ColorizeUi
+}
+
+func (m *GatewayClient) GateEndpoint() string {
+	if m.Config.Gate.Endpoint == "" && m.gateEndpoint == "" {
+		return "http://localhost:8084"
+	}
+	if m.gateEndpoint != "" {
+		return m.gateEndpoint
+	}
+	return m.Config.Gate.Endpoint
+}
+
+// New creates a Gate client configured by options, without reference to
+// command line flags. Requests made with the client's Context are aborted
+// when ctx is canceled.
+func New(ctx context.Context, options Options) (*GatewayClient, error) {
+	if options.MaxRetries < 0 {
+		return nil, fmt.Errorf("max retries must not be negative, got %d", options.MaxRetries)
+	}
+	gateClient := &GatewayClient{
+		gateEndpoint:     options.GateEndpoint,
+		ignoreCertErrors: options.Insecure,
+		proxyUrl:         options.ProxyUrl,
+		noProxy:          options.NoProxy,
+		headers:          options.Headers,
+		requestTimeout:   options.RequestTimeout,
+		maxRetries:       options.MaxRetries,
+		verbosity:        options.Verbosity,
+		traceFile:        options.TraceFile,
+		UI:               options.UI,
+	}
+	if gateClient.UI == nil {
+		ui, err := util.NewUI(false, util.ColorNever, "", os.Stdin, os.Stdout, os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		gateClient.UI = ui
+	}
+
+	err := gateClient.userConfig(options.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := gateClient.useContext(options.ContextName); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	gateClient.Context = ctx
+
+	// Api client initialization.
+	httpClient, err := gateClient.initializeClient()
+	if err != nil {
+		gateClient.UI.Error("Could not initialize http client, failing.")
+		return nil, err
+	}
+	gateClient.httpClient = httpClient
+
+	err = gateClient.authenticateOAuth2()
+	if err != nil {
+		gateClient.UI.Error("OAuth2 Authentication failed.")
+		return nil, err
+	}
+	cfg := &gate.Configuration{
+		BasePath:      gateClient.GateEndpoint(),
+		DefaultHeader: gateClient.gateHeaders(),
+		UserAgent:     fmt.Sprintf("%s/%s", version.UserAgent, version.String()),
+		HTTPClient:    httpClient,
+	}
+	gateClient.APIClient = gate.NewAPIClient(cfg)
+	return gateClient, nil
+}
+
+func (m *GatewayClient) userConfig(configLocation string) error {
+	if configLocation != "" {
+		m.configLocation = configLocation
+	} else {
+		userHome := ""
+		usr, err := user.Current()
+		if err != nil {
+			// Fallback by trying to read $HOME
+			userHome = os.Getenv("HOME")
+			if userHome == "" {
+				m.UI.Error("Could not read current user from environment, failing.")
+				return err
+			}
+		} else {
+			userHome = usr.HomeDir
+		}
+		m.configLocation = filepath.Join(userHome, ".spin", "config")
+	}
+	yamlFile, err := ioutil.ReadFile(m.configLocation)
+	if err != nil {
+		m.UI.Warn(fmt.Sprintf("Could not read configuration file from %s.", m.configLocation))
+	}
+
+	if yamlFile != nil {
+		err = yaml.UnmarshalStrict([]byte(os.ExpandEnv(string(yamlFile))), &m.Config)
+		if err != nil {
+			m.UI.Error(fmt.Sprintf("Could not deserialize config file with contents: %s, failing.", yamlFile))
+			return err
+		}
+	} else {
+		m.Config = config.Config{}
+	}
+	return nil
+}
+
+// useContext replaces the gate and auth settings with those of the named
+// context, keeping the config as read so cached tokens are saved in place.
+func (m *GatewayClient) useContext(name string) error {
+	m.fileConfig = m.Config
+	if name == "" {
+		return nil
+	}
+	selected, ok := m.Config.Contexts[name]
+	if !ok {
+		return util.NewUsageError("context %q is not defined in %s", name, m.configLocation)
+	}
+	m.Config.Gate = selected.Gate
+	m.Config.Auth = selected.Auth
+	return nil
+}
+
+// gateHeaders merges the configured headers with those from flags, flags taking precedence.
+func (m *GatewayClient) gateHeaders() map[string]string {
+	headers := make(map[string]string)
+	for name, value := range m.Config.Gate.Headers {
+		headers[name] = value
+	}
+	for name, value := range m.headers {
+		headers[name] = value
+	}
+	return headers
+}
+
+func (m *GatewayClient) timeout() time.Duration {
+	if m.requestTimeout != 0 {
+		return m.requestTimeout
+	}
+	if m.Config.Gate.Timeout != 0 {
+		return m.Config.Gate.Timeout
+	}
+	return defaultTimeout
+}
+
+// newTransport builds the transport shared by all Gate requests, honoring
+// the proxy, certificate, header, tracing and retry settings.
+func (m *GatewayClient) newTransport() (*http.Transport, http.RoundTripper, error) {
+	proxyUrl := m.proxyUrl
+	if proxyUrl == "" {
+		proxyUrl = m.Config.Gate.ProxyUrl
+	}
+	noProxy := m.noProxy
+	if noProxy == "" {
+		noProxy = m.Config.Gate.NoProxy
+	}
+	proxy, err := proxyFunc(proxyUrl, noProxy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.TLSClientConfig = &tls.Config{}
+	if m.ignoreCertErrors {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	var roundTripper http.RoundTripper = transport
+	if m.verbosity > 0 || m.traceFile != "" {
+		// Traced innermost so every attempt is recorded with the headers
+		// actually sent.
+		trace := &traceTransport{verbosity: m.verbosity, log: m.UI.DiagnosticWriter(), next: transport}
+		if m.traceFile != "" {
+			trace.har = newHarWriter(m.traceFile)
+		}
+		roundTripper = trace
+	}
+	roundTripper = &headerTransport{headers: m.gateHeaders(), next: roundTripper}
+	roundTripper = &retryTransport{maxRetries: m.maxRetries, next: roundTripper, timeout: m.timeout(), ui: m.UI}
+	return transport, roundTripper, nil
+}
+
+func (m *GatewayClient) initializeClient() (*http.Client, error) {
+	auth := m.Config.Auth
+	cookieJar, _ := cookiejar.New(nil)
+	transport, roundTripper, err := m.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{
+		Jar:       cookieJar,
+		Transport: roundTripper,
+	}
+
+	if auth != nil && auth.Enabled && auth.X509 != nil {
+		X509 := auth.X509
+
+		if err := X509.IsValid(); err != nil {
+			// Misconfigured.
+			return nil, err
+		}
+
+		cert, clientCA, err := m.loadX509KeyPair(X509)
+		if err != nil {
+			return nil, err
+		}
+		return m.initializeX509Config(client, transport, clientCA, cert), nil
+	} else if auth != nil && auth.Enabled && auth.Basic != nil {
+		if !auth.Basic.IsValid() {
+			return nil, errors.New("Incorrect Basic auth configuration. Must include username and password.")
+		}
+		m.Context = context.WithValue(m.Context, gate.ContextBasicAuth, gate.BasicAuth{
+			UserName: auth.Basic.Username,
+			Password: auth.Basic.Password,
+		})
+		return &client, nil
+	} else {
+		return &client, nil
+	}
+}
+
+func (m *GatewayClient) initializeX509Config(client http.Client, transport *http.Transport, clientCA []byte, cert tls.Certificate) *http.Client {
+	clientCertPool := x509.NewCertPool()
+	clientCertPool.AppendCertsFromPEM(clientCA)
+
+	transport.TLSClientConfig.MinVersion = tls.VersionTLS12
+	transport.TLSClientConfig.PreferServerCipherSuites = true
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	return &client
+}
+
+func (m *GatewayClient) authenticateOAuth2() error {
+	auth := m.Config.Auth
+	if auth != nil && auth.Enabled && auth.OAuth2 != nil {
+		OAuth2 := auth.OAuth2
+		if !OAuth2.IsValid() {
+			// TODO(jacobkiefer): Improve this error message.
+			return errors.New("incorrect OAuth2 auth configuration")
+		}
+
+		config := &oauth2.Config{
+			ClientID:     OAuth2.ClientId,
+			ClientSecret: OAuth2.ClientSecret,
+			RedirectURL:  "http://localhost:8085",
+			Scopes:       OAuth2.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  OAuth2.AuthUrl,
+				TokenURL: OAuth2.TokenUrl,
+			},
+		}
+		var newToken *oauth2.Token
+		var err error
+		// Route token requests through the configured proxy and headers.
+		tokenContext := context.WithValue(m.Context, oauth2.HTTPClient, m.httpClient)
+
+		if auth.OAuth2.CachedToken != nil {
+			// Look up cached credentials to save oauth2 roundtrip.
+			token := auth.OAuth2.CachedToken
+			tokenSource := config.TokenSource(tokenContext, token)
+			newToken, err = tokenSource.Token()
+			if err != nil {
+				m.UI.Error(fmt.Sprintf("Could not refresh token from source: %v", tokenSource))
+				return err
+			}
+		} else {
+			// Do roundtrip.
+			http.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				code := r.FormValue("code")
+				fmt.Fprintln(w, code)
+			}))
+			go http.ListenAndServe(":8085", nil)
+			// Note: leaving server connection open for scope of request, will be reaped on exit.
+
+			verifier, verifierCode, err := m.generateCodeVerifier()
+			if err != nil {
+				return err
+			}
+
+			codeVerifier := oauth2.SetAuthURLParam("code_verifier", verifier)
+			codeChallenge := oauth2.SetAuthURLParam("code_challenge", verifierCode)
+			challengeMethod := oauth2.SetAuthURLParam("code_challenge_method", "S256")
+
+			authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.ApprovalForce, challengeMethod, codeChallenge)
+			m.UI.Output(fmt.Sprintf("Navigate to %s and authenticate", authURL))
+			code := m.prompt()
+
+			newToken, err = config.Exchange(tokenContext, code, codeVerifier)
+			if err != nil {
+				return err
+			}
+		}
+
+		m.UI.Info("Caching oauth2 token.")
+		OAuth2.CachedToken = newToken
+		buf, _ := yaml.Marshal(&m.fileConfig)
+		info, _ := os.Stat(m.configLocation)
+		ioutil.WriteFile(m.configLocation, buf, info.Mode())
+
+		m.login(newToken.AccessToken)
+	}
+	return nil
+}
+
+func (m *GatewayClient) login(accessToken string) error {
+	loginReq, err := http.NewRequestWithContext(m.Context, "GET", m.GateEndpoint()+"/login", nil)
+	if err != nil {
+		return err
+	}
+	loginReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	m.httpClient.Do(loginReq) // Login to establish session.
+	return nil
+}
+
+// generateCodeVerifier generates an OAuth2 code verifier
+// in accordance to https://www.oauth.com/oauth2-servers/pkce/authorization-request and
+// https://tools.ietf.org/html/rfc7636#section-4.1.
+func (m *GatewayClient) generateCodeVerifier() (verifier string, code string, err error) {
+	randomBytes := make([]byte, 64)
+	if _, err := rand.Read(randomBytes); err != nil {
+		m.UI.Error("Could not generate random string for code_verifier")
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(randomBytes)
+	verifierHash := sha256.Sum256([]byte(verifier))
+	code = base64.RawURLEncoding.EncodeToString(verifierHash[:]) // Slice for type conversion
+	return verifier, code, nil
+}
+
+func (m *GatewayClient) prompt() string {
+	text, _ := m.UI.Ask("Paste authorization code:")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,383 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spinnaker/spin/util"
+)
+
+// requestIdHeaders are the response headers Spinnaker and common ingresses
+// use to identify a request, checked in order.
+var requestIdHeaders = []string{"X-Spinnaker-Request-Id", "X-Request-Id", "X-Amzn-Trace-Id", "X-Cloud-Trace-Context"}
+
+// GateError describes a failed call to Gate.
+type GateError struct {
+	// Message describes the operation that failed, e.g. "Encountered an error saving pipeline".
+	Message string
+
+	// StatusCode is the HTTP status of the response, or 0 if Gate couldn't be reached.
+	StatusCode int
+	Status     string
+	Method     string
+	URL        string
+	RequestId  string
+
+	// Body is the raw response body.
+	Body string
+	// SpringError is the parsed Spring error body, if the response had one.
+	SpringError *SpringError
+
+	// Cause is the underlying transport or client error, if any.
+	Cause error
+}
+
+// maxBodyLength caps how much of a non-JSON response body is shown, so
+// proxy and load balancer error pages don't flood the terminal.
+const maxBodyLength = 1024
+
+// SpringError is the error payload returned by Gate and other Spring Boot services.
+type SpringError struct {
+	Timestamp interface{} `json:"timestamp,omitempty"`
+	Status    int         `json:"status,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Exception string      `json:"exception,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Path      string      `json:"path,omitempty"`
+
+	// Errors lists individual failures, e.g. Front50 validation errors or
+	// pipeline template validation errors from Orca.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// UnmarshalJSON tolerates the variations between Spinnaker services: Orca
+// reports status as a string and errors[] entries may be plain strings,
+// Spring field errors or Orca validation errors.
+func (s *SpringError) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	s.Timestamp = raw["timestamp"]
+	if status, ok := raw["status"].(float64); ok {
+		s.Status = int(status)
+	}
+	s.Error, _ = raw["error"].(string)
+	s.Exception, _ = raw["exception"].(string)
+	s.Message, _ = raw["message"].(string)
+	s.Path, _ = raw["path"].(string)
+	s.Errors = errorMessages(raw["errors"])
+	return nil
+}
+
+// lines renders the error as the lines shown to the user.
+func (s *SpringError) lines() []string {
+	lines := []string{}
+	message := s.Message
+	if message == "" {
+		message = s.Error
+	}
+	if message != "" {
+		lines = append(lines, message)
+	}
+	for _, e := range s.Errors {
+		if e != message {
+			lines = append(lines, "  - "+e)
+		}
+	}
+	if s.Exception != "" {
+		lines = append(lines, "Exception: "+s.Exception)
+	}
+	return lines
+}
+
+// errorMessages flattens an errors[] payload into readable messages.
+func errorMessages(value interface{}) []string {
+	messages := []string{}
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			messages = append(messages, v)
+		}
+	case []interface{}:
+		for _, entry := range v {
+			messages = append(messages, errorMessages(entry)...)
+		}
+	case map[string]interface{}:
+		if message := errorEntryMessage(v); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// errorEntryMessage formats a single structured error, such as a Spring
+// field error ({field, defaultMessage}) or an Orca template validation
+// error ({location, message, suggestion}).
+func errorEntryMessage(entry map[string]interface{}) string {
+	message := ""
+	for _, key := range []string{"message", "defaultMessage", "error", "description"} {
+		if m, ok := entry[key].(string); ok && m != "" {
+			message = m
+			break
+		}
+	}
+	if message == "" {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+	for _, key := range []string{"field", "location"} {
+		if prefix, ok := entry[key].(string); ok && prefix != "" {
+			message = fmt.Sprintf("%s: %s", prefix, message)
+			break
+		}
+	}
+	if suggestion, ok := entry["suggestion"].(string); ok && suggestion != "" {
+		message = fmt.Sprintf("%s (%s)", message, suggestion)
+	}
+	return message
+}
+
+// NewGateError wraps the response and error returned by a generated Gate
+// API call. message describes the failed operation.
+func NewGateError(message string, resp *http.Response, err error) error {
+	gateErr := &GateError{Message: message, Cause: err}
+	if resp != nil {
+		gateErr.StatusCode = resp.StatusCode
+		gateErr.Status = resp.Status
+		if resp.Request != nil {
+			gateErr.Method = resp.Request.Method
+			gateErr.URL = resp.Request.URL.String()
+		}
+		for _, header := range requestIdHeaders {
+			if id := resp.Header.Get(header); id != "" {
+				gateErr.RequestId = id
+				break
+			}
+		}
+	}
+	if err != nil {
+		// The generated client reports non-2xx responses as "Status: <status>, Body: <body>".
+		errMessage := err.Error()
+		if idx := strings.Index(errMessage, ", Body: "); strings.HasPrefix(errMessage, "Status: ") && idx >= 0 {
+			gateErr.Body = errMessage[idx+len(", Body: "):]
+			gateErr.Cause = nil
+		}
+	}
+	if gateErr.Body != "" {
+		springError := &SpringError{}
+		if json.Unmarshal([]byte(gateErr.Body), springError) == nil && len(springError.lines()) > 0 {
+			gateErr.SpringError = springError
+		}
+	}
+	return gateErr
+}
+
+func (e *GateError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, ", status code: %d", e.StatusCode)
+		if text := http.StatusText(e.StatusCode); text != "" {
+			fmt.Fprintf(&b, " (%s)", text)
+		}
+	}
+	if e.Cause != nil {
+		fmt.Fprintf(&b, ": %v", e.Cause)
+	}
+	if detail := e.detail(); detail != "" {
+		fmt.Fprintf(&b, "\n%s", detail)
+	}
+	if e.RequestId != "" {
+		fmt.Fprintf(&b, "\nRequest id: %s", e.RequestId)
+	}
+	return b.String()
+}
+
+// detail returns the most useful description of the failure from the response.
+func (e *GateError) detail() string {
+	if e.SpringError != nil {
+		return strings.Join(e.SpringError.lines(), "\n")
+	}
+	body := strings.TrimSpace(e.Body)
+	if len(body) > maxBodyLength {
+		body = body[:maxBodyLength] + "... (truncated)"
+	}
+	return body
+}
+
+func (e *GateError) Unwrap() error {
+	return e.Cause
+}
+
+// ExitCode maps the response status onto spin's exit codes.
+func (e *GateError) ExitCode() int {
+	switch {
+	case e.StatusCode == 0:
+		// Gate wasn't reached; report network failures as such.
+		if e.Cause == nil {
+			return util.ExitError
+		}
+		return util.ExitCode(e.Cause)
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return util.ExitInvalid
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return util.ExitAuth
+	case e.StatusCode == http.StatusNotFound:
+		return util.ExitNotFound
+	case e.StatusCode == http.StatusConflict:
+		return util.ExitConflict
+	case e.StatusCode >= 500:
+		return util.ExitServer
+	}
+	return util.ExitError
+}
+
+// ErrorDetails exposes the Gate response in JSON error output.
+func (e *GateError) ErrorDetails() map[string]interface{} {
+	details := map[string]interface{}{}
+	if e.StatusCode != 0 {
+		details["status"] = e.StatusCode
+	}
+	if e.Method != "" {
+		details["method"] = e.Method
+		details["url"] = e.URL
+	}
+	if e.RequestId != "" {
+		details["requestId"] = e.RequestId
+	}
+	if e.SpringError != nil {
+		details["gateError"] = e.SpringError
+	} else if e.Body != "" {
+		details["body"] = e.Body
+	}
+	if e.Cause != nil {
+		details["cause"] = e.Cause.Error()
+	}
+	return details
+}
+
+// TaskError describes an Orca task that finished without succeeding.
+type TaskError struct {
+	// Message describes the operation that failed, e.g. "Encountered an error saving application".
+	Message string
+	TaskId  string
+	Status  string
+
+	// Errors are the failures Orca recorded against the task and its stages.
+	Errors []string
+	// Task is the raw task, included when Orca recorded no errors.
+	Task map[string]interface{}
+}
+
+// NewTaskError builds an error from a failed task as returned by
+// TaskControllerApi.GetTaskUsingGET1.
+func NewTaskError(message string, task map[string]interface{}) error {
+	taskErr := &TaskError{Message: message, Task: task}
+	taskErr.TaskId, _ = task["id"].(string)
+	taskErr.Status, _ = task["status"].(string)
+
+	if variables, ok := task["variables"].([]interface{}); ok {
+		for _, variable := range variables {
+			if v, ok := variable.(map[string]interface{}); ok && v["key"] == "exception" {
+				taskErr.addErrors(exceptionMessages(v["value"]))
+			}
+		}
+	}
+	if execution, ok := task["execution"].(map[string]interface{}); ok {
+		if stages, ok := execution["stages"].([]interface{}); ok {
+			for _, stage := range stages {
+				if s, ok := stage.(map[string]interface{}); ok {
+					if context, ok := s["context"].(map[string]interface{}); ok {
+						taskErr.addErrors(exceptionMessages(context["exception"]))
+					}
+				}
+			}
+		}
+	}
+	return taskErr
+}
+
+func (e *TaskError) addErrors(messages []string) {
+	for _, message := range messages {
+		duplicate := false
+		for _, existing := range e.Errors {
+			duplicate = duplicate || existing == message
+		}
+		if !duplicate {
+			e.Errors = append(e.Errors, message)
+		}
+	}
+}
+
+// exceptionMessages extracts the messages from an Orca exception, which
+// looks like {"exceptionType": ..., "details": {"error": ..., "errors": [...]}}.
+func exceptionMessages(value interface{}) []string {
+	exception, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	details, ok := exception["details"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	messages := errorMessages(details["errors"])
+	if len(messages) == 0 {
+		messages = errorMessages(details["error"])
+	}
+	return messages
+}
+
+func (e *TaskError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	if e.Status != "" {
+		fmt.Fprintf(&b, ", task status: %s", e.Status)
+	}
+	if e.TaskId != "" {
+		fmt.Fprintf(&b, "\nTask id: %s", e.TaskId)
+	}
+	for _, message := range e.Errors {
+		fmt.Fprintf(&b, "\n  - %s", message)
+	}
+	if len(e.Errors) == 0 {
+		fmt.Fprintf(&b, "\nTask output was: %v", e.Task)
+	}
+	return b.String()
+}
+
+// ErrorDetails exposes the task in JSON error output.
+func (e *TaskError) ErrorDetails() map[string]interface{} {
+	details := map[string]interface{}{}
+	if e.TaskId != "" {
+		details["taskId"] = e.TaskId
+	}
+	if e.Status != "" {
+		details["taskStatus"] = e.Status
+	}
+	if len(e.Errors) > 0 {
+		details["errors"] = e.Errors
+	} else {
+		details["task"] = e.Task
+	}
+	return details
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestNewGateError_springBody(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://localhost:8084/pipelines", nil)
+	resp := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Status:     "400 Bad Request",
+		Header:     http.Header{"X-Spinnaker-Request-Id": []string{"abc-123"}},
+		Request:    req,
+	}
+	body := `{"timestamp":1544475186050,"status":400,"error":"Bad Request","message":"Pipeline name is required","path":"/pipelines"}`
+	err := NewGateError("Encountered an error saving pipeline", resp, errors.New("Status: 400 Bad Request, Body: "+body))
+
+	var gateErr *GateError
+	if !errors.As(err, &gateErr) {
+		t.Fatalf("Expected a GateError, got %T", err)
+	}
+	if gateErr.SpringError == nil || gateErr.SpringError.Message != "Pipeline name is required" {
+		t.Fatalf("Expected the Spring error body to be parsed, got %+v", gateErr)
+	}
+	for _, expected := range []string{"status code: 400", "Pipeline name is required", "abc-123"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error message to contain %q, got: %s", expected, err)
+		}
+	}
+	if code := util.ExitCode(err); code != util.ExitInvalid {
+		t.Fatalf("Expected exit code %d, got %d", util.ExitInvalid, code)
+	}
+}
+
+func TestNewGateError_errorList(t *testing.T) {
+	cases := map[string][]string{
+		// Front50 validation failure.
+		`{"status":400,"error":"Bad Request","message":"Validation failed","errors":["A pipeline with name 'one' already exists in application app"]}`: {
+			"Validation failed", "  - A pipeline with name 'one' already exists in application app",
+		},
+		// Spring field errors.
+		`{"status":400,"error":"Bad Request","errors":[{"field":"name","defaultMessage":"must not be empty"}]}`: {
+			"Bad Request", "  - name: must not be empty",
+		},
+		// Orca pipeline template validation, which reports status as a string.
+		`{"status":"BAD_REQUEST","message":"Pipeline template is invalid","errors":[{"severity":"FATAL","location":"stages.wait","message":"Stage ID is unset","suggestion":"set an id"}]}`: {
+			"Pipeline template is invalid", "  - stages.wait: Stage ID is unset (set an id)",
+		},
+		`{"status":500,"error":"Internal Server Error","exception":"retrofit.RetrofitError","message":"timeout"}`: {
+			"timeout", "Exception: retrofit.RetrofitError",
+		},
+	}
+	for body, expected := range cases {
+		resp := &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}}
+		err := NewGateError("Encountered an error", resp, errors.New("Status: 400 Bad Request, Body: "+body))
+		lines := strings.Split(err.Error(), "\n")[1:]
+		if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Body %s: expected lines %q, got %q", body, expected, lines)
+		}
+	}
+}
+
+func TestNewGateError_htmlBody(t *testing.T) {
+	body := "<html>" + strings.Repeat("x", 2*maxBodyLength) + "</html>"
+	resp := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}
+	err := NewGateError("Encountered an error", resp, errors.New("Status: 502 Bad Gateway, Body: "+body))
+	if !strings.HasSuffix(err.Error(), "(truncated)") || len(err.Error()) > 2*maxBodyLength {
+		t.Fatalf("Expected the body to be truncated, got %d bytes", len(err.Error()))
+	}
+}
+
+func TestNewTaskError(t *testing.T) {
+	task := map[string]interface{}{
+		"id":     "01ABC",
+		"status": "TERMINAL",
+		"variables": []interface{}{
+			map[string]interface{}{"key": "exception", "value": map[string]interface{}{
+				"details": map[string]interface{}{"error": "Unexpected Task Failure", "errors": []interface{}{"Application 'app' already exists"}},
+			}},
+		},
+		"execution": map[string]interface{}{
+			"stages": []interface{}{
+				map[string]interface{}{"context": map[string]interface{}{"exception": map[string]interface{}{
+					"details": map[string]interface{}{"errors": []interface{}{"Application 'app' already exists", "Owner email is invalid"}},
+				}}},
+			},
+		},
+	}
+	err := NewTaskError("Encountered an error saving application", task)
+	expected := "Encountered an error saving application, task status: TERMINAL\nTask id: 01ABC\n  - Application 'app' already exists\n  - Owner email is invalid"
+	if err.Error() != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, err)
+	}
+
+	err = NewTaskError("Encountered an error", map[string]interface{}{"status": "TERMINAL"})
+	if !strings.Contains(err.Error(), "Task output was") {
+		t.Fatalf("Expected the raw task when no errors were recorded, got: %s", err)
+	}
+}
+
+func TestGateError_exitCodes(t *testing.T) {
+	cases := map[int]int{
+		http.StatusUnauthorized:        util.ExitAuth,
+		http.StatusForbidden:           util.ExitAuth,
+		http.StatusNotFound:            util.ExitNotFound,
+		http.StatusConflict:            util.ExitConflict,
+		http.StatusInternalServerError: util.ExitServer,
+		http.StatusBadGateway:          util.ExitServer,
+		http.StatusOK:                  util.ExitError,
+	}
+	for status, expected := range cases {
+		err := NewGateError("Encountered an error", &http.Response{StatusCode: status, Header: http.Header{}}, nil)
+		if code := util.ExitCode(err); code != expected {
+			t.Errorf("Status %d: expected exit code %d, got %d", status, expected, code)
+		}
+	}
+
+	networkErr := &url.Error{Op: "Get", URL: "http://localhost:8084", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	if code := util.ExitCode(NewGateError("Encountered an error", nil, networkErr)); code != util.ExitNetwork {
+		t.Errorf("Expected network errors to exit with %d, got %d", util.ExitNetwork, code)
+	}
+}
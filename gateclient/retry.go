@@ -0,0 +1,235 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+const (
+	backoffBase = 500 * time.Millisecond
+	backoffMax  = 30 * time.Second
+)
+
+type retrySafeKey struct{}
+
+// RetrySafe marks requests made with the returned context as safe to retry
+// even though their HTTP method is not idempotent, e.g. a POST that only
+// computes a result such as a pipeline template plan.
+func RetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+// Backoff returns how long to wait before the given (zero-based) attempt,
+// growing exponentially from 500ms up to 30s with jitter so concurrent
+// clients don't retry in lockstep.
+func Backoff(attempt int) time.Duration {
+	if attempt > 16 {
+		attempt = 16
+	}
+	delay := backoffBase << uint(attempt)
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	// Equal jitter: keep half of the delay, randomize the rest.
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Sleep pauses for d, returning early with the context's error if ctx is
+// canceled first. Polling loops use it so Ctrl-C isn't held up by a wait.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryTransport retries requests that failed with a transient error: a
+// dropped connection or a 429/502/503/504 from Gate or a proxy in front of
+// it. Only idempotent requests (or those marked with RetrySafe) are retried
+// after they may have reached the server; any request is retried if the
+// connection could not be established at all.
+type retryTransport struct {
+	maxRetries int
+	next       http.RoundTripper
+
+	// timeout, if set, bounds each attempt, including reading its response
+	// body, rather than all attempts together.
+	timeout time.Duration
+
+	// ui, if set, is warned about each retry.
+	ui cli.Ui
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.roundTrip(req)
+		if attempt >= t.maxRetries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			// The body has been consumed and can't be replayed.
+			return resp, err
+		}
+
+		delay := Backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			t.warn(fmt.Sprintf("%s %s returned %s, retrying in %v...", req.Method, req.URL.Path, resp.Status, delay.Round(time.Millisecond)))
+		} else {
+			t.warn(fmt.Sprintf("%s %s failed: %v, retrying in %v...", req.Method, req.URL.Path, err, delay.Round(time.Millisecond)))
+		}
+
+		if err := Sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// roundTrip makes a single attempt, bounded by the timeout if one is set.
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The deadline stays in force until the caller is done with the body.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases an attempt's context once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (t *retryTransport) warn(message string) {
+	if t.ui != nil {
+		t.ui.Warn(message)
+	}
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if isDialError(err) {
+			// The request never left the client.
+			return true
+		}
+		return isIdempotent(req) && isTransientError(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		// The server explicitly rejected the request without processing it.
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req)
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	if safe, _ := req.Context().Value(retrySafeKey{}).(bool); safe {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func isTransientError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return capDelay(time.Duration(seconds) * time.Second), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return capDelay(delay), true
+	}
+	return 0, false
+}
+
+func capDelay(delay time.Duration) time.Duration {
+	if delay > 2*backoffMax {
+		return 2 * backoffMax
+	}
+	return delay
+}
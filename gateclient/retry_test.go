@@ -0,0 +1,128 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport_retriesIdempotent(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &retryTransport{maxRetries: 3, next: http.DefaultTransport}}
+	req, _ := http.NewRequest("PUT", ts.URL, bytes.NewBufferString("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || attempts != 3 || string(body) != "payload" {
+		t.Fatalf("Expected success with replayed body after 3 attempts, got status %d after %d attempts, body %q",
+			resp.StatusCode, attempts, body)
+	}
+}
+
+func TestRetryTransport_skipsNonIdempotent(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &retryTransport{maxRetries: 3, next: http.DefaultTransport}}
+	req, _ := http.NewRequest("POST", ts.URL, bytes.NewBufferString("{}"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if resp.StatusCode != http.StatusBadGateway || attempts != 1 {
+		t.Fatalf("Expected a single POST attempt, got status %d after %d attempts", resp.StatusCode, attempts)
+	}
+
+	attempts = 0
+	req, _ = http.NewRequest("POST", ts.URL, bytes.NewBufferString("{}"))
+	req = req.WithContext(RetrySafe(req.Context()))
+	client.Do(req)
+	if attempts != 4 {
+		t.Fatalf("Expected a RetrySafe POST to be retried 3 times, got %d attempts", attempts)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("7"); !ok || d != 7*time.Second {
+		t.Fatalf("Expected 7s, got %v", d)
+	}
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(date); !ok || d <= 0 || d > 10*time.Second {
+		t.Fatalf("Expected up to 10s, got %v", d)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Fatal("Expected an unparseable Retry-After to be ignored")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		d := Backoff(attempt)
+		if d < backoffBase/2 || d > backoffMax {
+			t.Fatalf("Backoff(%d) = %v out of range", attempt, d)
+		}
+	}
+}
+
+func TestRetryTransport_timeoutPerAttempt(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte("done"))
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &retryTransport{maxRetries: 1, next: http.DefaultTransport, timeout: 200 * time.Millisecond}}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Expected the retry to get a fresh timeout, got: %s", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "done" || attempts != 2 {
+		t.Fatalf("Expected success on the second attempt, got body %q after %d attempts", body, attempts)
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spinnaker/spin/version"
+)
+
+// Verbosity levels for --verbose.
+const (
+	// VerboseRequests logs the method, url, status and timing of each request.
+	VerboseRequests = 1
+	// VerboseHeaders additionally logs request and response headers.
+	VerboseHeaders = 2
+	// VerboseBodies additionally logs request and response bodies.
+	VerboseBodies = 3
+
+	// maxLoggedBody caps how much of a body is logged to the terminal.
+	maxLoggedBody = 64 * 1024
+	redacted      = "REDACTED"
+)
+
+var (
+	sensitiveHeader = regexp.MustCompile(`(?i)authorization|cookie|token|secret|password|api-?key|iap-jwt`)
+	sensitiveField  = regexp.MustCompile(`(?i)password|passphrase|secret|token|credential|authorization|apikey|api_key|privatekey|private_key`)
+)
+
+// traceTransport logs each request to log according to the verbosity level
+// and, if a trace file is configured, records it in an HTTP Archive.
+type traceTransport struct {
+	verbosity int
+	har       *harWriter
+	log       io.Writer
+	next      http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	captureBodies := t.har != nil || t.verbosity >= VerboseBodies
+
+	var reqBody []byte
+	if captureBodies && req.Body != nil {
+		var err error
+		reqBody, err = readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	if t.verbosity >= VerboseRequests {
+		fmt.Fprintf(t.log, "> %s %s\n", req.Method, req.URL)
+	}
+	if t.verbosity >= VerboseHeaders {
+		logHeaders(t.log, "> ", req.Header)
+	}
+	if t.verbosity >= VerboseBodies && len(reqBody) > 0 {
+		logBody(t.log, "> ", reqBody)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		if t.verbosity >= VerboseRequests {
+			fmt.Fprintf(t.log, "< %s %s failed after %v: %v\n", req.Method, req.URL, elapsed.Round(time.Millisecond), err)
+		}
+		return resp, err
+	}
+
+	var respBody []byte
+	if captureBodies {
+		respBody, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	}
+
+	if t.verbosity >= VerboseRequests {
+		fmt.Fprintf(t.log, "< %s (%v)\n", resp.Status, elapsed.Round(time.Millisecond))
+	}
+	if t.verbosity >= VerboseHeaders {
+		logHeaders(t.log, "< ", resp.Header)
+	}
+	if t.verbosity >= VerboseBodies && len(respBody) > 0 {
+		logBody(t.log, "< ", respBody)
+	}
+
+	if t.har != nil {
+		if err := t.har.add(newHarEntry(req, reqBody, resp, respBody, start, elapsed)); err != nil {
+			fmt.Fprintf(t.log, "Could not write trace file: %v\n", err)
+		}
+	}
+	return resp, nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	defer req.Body.Close()
+	return ioutil.ReadAll(req.Body)
+}
+
+func logHeaders(w io.Writer, prefix string, headers http.Header) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range headers[name] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, name, redactHeader(name, value))
+		}
+	}
+}
+
+func logBody(w io.Writer, prefix string, body []byte) {
+	text := redactBody(body)
+	if len(text) > maxLoggedBody {
+		text = text[:maxLoggedBody] + fmt.Sprintf("... (%d bytes truncated)", len(text)-maxLoggedBody)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+}
+
+func redactHeader(name, value string) string {
+	if sensitiveHeader.MatchString(name) {
+		return redacted
+	}
+	return value
+}
+
+// redactBody masks the values of sensitive-looking fields in JSON bodies.
+// Non-JSON bodies are returned unchanged.
+func redactBody(body []byte) string {
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return string(body)
+	}
+	redacted, err := json.MarshalIndent(redactValue(parsed), "", "  ")
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if _, isString := field.(string); isString && sensitiveField.MatchString(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// harWriter accumulates HTTP Archive (HAR 1.2) entries and rewrites the
+// trace file after each one, so the trace survives a failing command.
+type harWriter struct {
+	path string
+
+	mu  sync.Mutex
+	har harFile
+}
+
+func newHarWriter(path string) *harWriter {
+	return &harWriter{
+		path: path,
+		har: harFile{Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: version.UserAgent, Version: version.String()},
+			Entries: []harEntry{},
+		}},
+	}
+}
+
+func (h *harWriter) add(entry harEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.har.Log.Entries = append(h.har.Log.Entries, entry)
+	buf, err := json.MarshalIndent(h.har, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.path, buf, 0600)
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func newHarEntry(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, start time.Time, elapsed time.Duration) harEntry {
+	millis := float64(elapsed) / float64(time.Millisecond)
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            millis,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(resp.Header),
+			Content: harContent{
+				Size:     len(respBody),
+				MimeType: resp.Header.Get("Content-Type"),
+				Text:     redactBody(respBody),
+			},
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		},
+		Timings: harTimings{Send: 0, Wait: millis, Receive: 0},
+	}
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     redactBody(reqBody),
+		}
+	}
+	return entry
+}
+
+func harHeaders(headers http.Header) []harNameValue {
+	result := []harNameValue{}
+	for name, values := range headers {
+		for _, value := range values {
+			result = append(result, harNameValue{Name: name, Value: redactHeader(name, value)})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spinnaker/spin/util"
+)
+
+func TestTraceTransport_verbose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "app", "token": "s3cret"}`))
+	}))
+	defer ts.Close()
+
+	log := &bytes.Buffer{}
+	client := &http.Client{Transport: &traceTransport{verbosity: VerboseBodies, log: log, next: http.DefaultTransport}}
+	req, _ := http.NewRequest("POST", ts.URL+"/pipelines", strings.NewReader(`{"password": "hunter2"}`))
+	req.Header.Set("Authorization", "Bearer abc")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "s3cret") {
+		t.Fatalf("Response body was not passed through intact: %s", body)
+	}
+
+	output := log.String()
+	for _, expected := range []string{"> POST " + ts.URL + "/pipelines", "< 200 OK", "Authorization: REDACTED", `"name": "app"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected trace to contain %q, got:\n%s", expected, output)
+		}
+	}
+	for _, secret := range []string{"hunter2", "s3cret", "Bearer abc"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Trace leaked %q:\n%s", secret, output)
+		}
+	}
+}
+
+func TestNew_traceToUI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	errOut := &bytes.Buffer{}
+	ui, err := util.NewUI(false, util.ColorNever, "", strings.NewReader(""), ioutil.Discard, errOut)
+	if err != nil {
+		t.Fatalf("Could not create UI: %s", err)
+	}
+	gateClient, err := New(context.Background(), Options{ConfigPath: "/dev/null", GateEndpoint: ts.URL, Verbosity: VerboseRequests, UI: ui})
+	if err != nil {
+		t.Fatalf("Could not create client: %s", err)
+	}
+	if _, err := gateClient.httpClient.Get(ts.URL + "/applications"); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if !strings.Contains(errOut.String(), "> GET "+ts.URL+"/applications") {
+		t.Fatalf("Expected the trace on the UI's error output, got %q", errOut.String())
+	}
+}
+
+func TestNew_traceShowsConfiguredHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	errOut := &bytes.Buffer{}
+	ui, err := util.NewUI(false, util.ColorNever, "", strings.NewReader(""), ioutil.Discard, errOut)
+	if err != nil {
+		t.Fatalf("Could not create UI: %s", err)
+	}
+	headers := map[string]string{"X-Team": "platform", "X-Api-Key": "hunter2"}
+	gateClient, err := New(context.Background(), Options{ConfigPath: "/dev/null", GateEndpoint: ts.URL, Headers: headers, Verbosity: VerboseHeaders, UI: ui})
+	if err != nil {
+		t.Fatalf("Could not create client: %s", err)
+	}
+	if _, err := gateClient.httpClient.Get(ts.URL + "/applications"); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	trace := errOut.String()
+	if !strings.Contains(trace, "X-Team: platform") {
+		t.Fatalf("Expected the configured header in the trace, got %q", trace)
+	}
+	if strings.Contains(trace, "hunter2") || !strings.Contains(trace, "X-Api-Key: REDACTED") {
+		t.Fatalf("Expected the sensitive header to be redacted, got %q", trace)
+	}
+}
+
+func TestTraceTransport_har(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	traceFile, _ := ioutil.TempFile("", "spin-trace")
+	traceFile.Close()
+	defer os.Remove(traceFile.Name())
+
+	client := &http.Client{Transport: &traceTransport{har: newHarWriter(traceFile.Name()), log: ioutil.Discard, next: http.DefaultTransport}}
+	client.Get(ts.URL + "/applications?expand=false")
+	client.Get(ts.URL + "/pipelines/app")
+
+	var har harFile
+	buf, _ := ioutil.ReadFile(traceFile.Name())
+	if err := json.Unmarshal(buf, &har); err != nil {
+		t.Fatalf("Trace file is not valid json: %s", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Fatalf("Expected a HAR 1.2 log with 2 entries, got: %s", buf)
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.Method != "GET" || entry.Response.Status != http.StatusNotFound || len(entry.Request.QueryString) != 1 {
+		t.Fatalf("Unexpected HAR entry: %+v", entry)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// proxyFunc returns the proxy selection function for the transport. An
+// explicit proxyUrl applies to both http and https endpoints; otherwise the
+// standard proxy environment variables are honored. noProxy, when set,
+// overrides NO_PROXY.
+func proxyFunc(proxyUrl, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	proxyConfig := httpproxy.FromEnvironment()
+	if proxyUrl != "" {
+		if _, err := url.Parse(proxyUrl); err != nil {
+			return nil, fmt.Errorf("Could not parse proxy url %s: %v", proxyUrl, err)
+		}
+		proxyConfig.HTTPProxy = proxyUrl
+		proxyConfig.HTTPSProxy = proxyUrl
+	}
+	if noProxy != "" {
+		proxyConfig.NoProxy = noProxy
+	}
+
+	proxy := proxyConfig.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+// headerTransport adds the configured headers to every request that does
+// not already carry them, so requests made outside the generated API
+// client (e.g. the OAuth2 login) pass through the same ingress.
+type headerTransport struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.headers) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
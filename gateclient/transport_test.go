@@ -0,0 +1,63 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "blue" || r.Header.Get("X-Explicit") != "kept" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &headerTransport{
+		headers: map[string]string{"X-Tenant": "blue", "X-Explicit": "overridden"},
+		next:    http.DefaultTransport,
+	}}
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("X-Explicit", "kept")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Server did not receive the expected headers, status: %d", resp.StatusCode)
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	proxy, err := proxyFunc("http://proxy.example.com:3128", "gate.internal")
+	if err != nil {
+		t.Fatalf("Failed to build proxy func: %s", err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://gate.example.com/applications", nil)
+	proxyUrl, err := proxy(req)
+	if err != nil || proxyUrl == nil || proxyUrl.Host != "proxy.example.com:3128" {
+		t.Fatalf("Expected request to be proxied, got: %v, %v", proxyUrl, err)
+	}
+
+	req, _ = http.NewRequest("GET", "https://gate.internal/applications", nil)
+	proxyUrl, err = proxy(req)
+	if err != nil || proxyUrl != nil {
+		t.Fatalf("Expected no-proxy host to bypass the proxy, got: %v, %v", proxyUrl, err)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/youmark/pkcs8"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+
+	x509config "github.com/spinnaker/spin/config/auth/x509"
+)
+
+// loadX509KeyPair builds the client certificate described by the x509 auth
+// config. It returns the certificate along with the PEM encoded certificate
+// chain that should be trusted alongside it.
+func (m *GatewayClient) loadX509KeyPair(X509 *x509config.X509Config) (tls.Certificate, []byte, error) {
+	var certBytes, keyBytes []byte
+	var err error
+
+	switch {
+	case X509.Pkcs12Path != "":
+		return m.loadPkcs12(X509)
+	case X509.CertPath != "" && X509.KeyPath != "":
+		certBytes, err = readExpanded(X509.CertPath)
+		if err != nil {
+			return tls.Certificate{}, nil, err
+		}
+		keyBytes, err = readExpanded(X509.KeyPath)
+		if err != nil {
+			return tls.Certificate{}, nil, err
+		}
+	default:
+		certBytes = []byte(X509.Cert)
+		keyBytes = []byte(X509.Key)
+	}
+
+	keyBytes, err = m.decryptKey(keyBytes, X509.Passphrase)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	if X509.ChainPath != "" {
+		chainBytes, err := readExpanded(X509.ChainPath)
+		if err != nil {
+			return tls.Certificate{}, nil, err
+		}
+		// tls.X509KeyPair treats every certificate after the first as part of the chain.
+		certBytes = append(append(certBytes, '\n'), chainBytes...)
+	}
+
+	cert, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("Could not load x509 key pair: %v", err)
+	}
+	return cert, certBytes, nil
+}
+
+func (m *GatewayClient) loadPkcs12(X509 *x509config.X509Config) (tls.Certificate, []byte, error) {
+	data, err := readExpanded(X509.Pkcs12Path)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	password := ""
+	if X509.Passphrase != nil {
+		password, err = m.resolvePassphrase(X509.Passphrase)
+		if err != nil {
+			return tls.Certificate{}, nil, err
+		}
+	}
+
+	// Handles both the AES bundles OpenSSL 3 writes by default and legacy
+	// 3DES/RC2 ones.
+	key, leaf, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		if err == pkcs12.ErrIncorrectPassword && X509.Passphrase == nil {
+			return tls.Certificate{}, nil, fmt.Errorf("PKCS#12 bundle %s is password protected; configure x509.passphrase", X509.Pkcs12Path)
+		}
+		return tls.Certificate{}, nil, fmt.Errorf("Could not decode PKCS#12 bundle %s: %v", X509.Pkcs12Path, err)
+	}
+
+	var certBytes []byte
+	for _, cert := range append([]*x509.Certificate{leaf}, chain...) {
+		certBytes = append(certBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("Could not read the private key of PKCS#12 bundle %s: %v", X509.Pkcs12Path, err)
+	}
+	keyBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	cert, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("Could not load key pair from PKCS#12 bundle %s: %v", X509.Pkcs12Path, err)
+	}
+	return cert, certBytes, nil
+}
+
+// decryptKey returns an unencrypted PEM private key, decrypting legacy
+// "Proc-Type: 4,ENCRYPTED" blocks and PKCS#8 "ENCRYPTED PRIVATE KEY" blocks
+// with the configured passphrase.
+func (m *GatewayClient) decryptKey(keyBytes []byte, passphrase *x509config.PassphraseConfig) ([]byte, error) {
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return keyBytes, nil
+	}
+
+	legacy := x509.IsEncryptedPEMBlock(block)
+	if !legacy && block.Type != "ENCRYPTED PRIVATE KEY" {
+		return keyBytes, nil
+	}
+	if passphrase == nil {
+		return nil, errors.New("x509 private key is encrypted; configure x509.passphrase with 'env', 'command' or 'prompt: true'")
+	}
+
+	password, err := m.resolvePassphrase(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if legacy {
+		der, err := x509.DecryptPEMBlock(block, []byte(password))
+		if err != nil {
+			return nil, fmt.Errorf("Could not decrypt x509 private key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	}
+
+	key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+	if err != nil {
+		return nil, fmt.Errorf("Could not decrypt x509 private key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func (m *GatewayClient) resolvePassphrase(passphrase *x509config.PassphraseConfig) (string, error) {
+	switch {
+	case passphrase.Env != "":
+		value, ok := os.LookupEnv(passphrase.Env)
+		if !ok {
+			return "", fmt.Errorf("x509 passphrase environment variable %s is not set", passphrase.Env)
+		}
+		return value, nil
+	case passphrase.Command != "":
+		var stderr bytes.Buffer
+		command := exec.Command("sh", "-c", passphrase.Command)
+		command.Stderr = &stderr
+		out, err := command.Output()
+		if err != nil {
+			return "", fmt.Errorf("x509 passphrase command failed: %v\n%s", err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+	case passphrase.Prompt:
+		return m.UI.AskSecret("x509 key passphrase:")
+	default:
+		return "", errors.New("x509 passphrase source not configured")
+	}
+}
+
+func readExpanded(path string) ([]byte, error) {
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(expanded)
+}
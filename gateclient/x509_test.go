@@ -0,0 +1,119 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gateclient
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	x509config "github.com/spinnaker/spin/config/auth/x509"
+	"github.com/spinnaker/spin/util"
+)
+
+// The fixtures in testdata are a self-signed P-256 certificate and its key:
+// client.key unencrypted, client-legacy.key as an AES-128 "Proc-Type:
+// 4,ENCRYPTED" block, client-pkcs8.key as an AES-256 PKCS#8 block and
+// client.p12 as a legacy 3DES PKCS#12 bundle and client-aes.p12 as an AES-256
+// bundle, OpenSSL 3's default, all with the passphrase "secret".
+
+func TestDecryptKey(t *testing.T) {
+	t.Setenv("SPIN_TEST_PASSPHRASE", "secret")
+	certBytes := readTestdata(t, "client.crt")
+	m := &GatewayClient{}
+
+	for _, file := range []string{"client.key", "client-legacy.key", "client-pkcs8.key"} {
+		keyBytes, err := m.decryptKey(readTestdata(t, file), &x509config.PassphraseConfig{Env: "SPIN_TEST_PASSPHRASE"})
+		if err != nil {
+			t.Fatalf("Could not decrypt %s: %v", file, err)
+		}
+		if _, err := tls.X509KeyPair(certBytes, keyBytes); err != nil {
+			t.Fatalf("Decrypted %s does not match the certificate: %v", file, err)
+		}
+	}
+
+	if _, err := m.decryptKey(readTestdata(t, "client-legacy.key"), nil); err == nil || !strings.Contains(err.Error(), "encrypted") {
+		t.Fatalf("Expected an encrypted key without a passphrase to fail, got %v", err)
+	}
+	t.Setenv("SPIN_TEST_PASSPHRASE", "wrong")
+	for _, file := range []string{"client-legacy.key", "client-pkcs8.key"} {
+		if _, err := m.decryptKey(readTestdata(t, file), &x509config.PassphraseConfig{Env: "SPIN_TEST_PASSPHRASE"}); err == nil {
+			t.Fatalf("Expected %s to fail with the wrong passphrase", file)
+		}
+	}
+}
+
+func TestLoadPkcs12(t *testing.T) {
+	m := &GatewayClient{}
+	for _, file := range []string{"client.p12", "client-aes.p12"} {
+		config := &x509config.X509Config{
+			Pkcs12Path: "testdata/" + file,
+			Passphrase: &x509config.PassphraseConfig{Command: "echo secret"},
+		}
+		cert, certBytes, err := m.loadPkcs12(config)
+		if err != nil {
+			t.Fatalf("Could not load %s: %v", file, err)
+		}
+		if len(cert.Certificate) != 1 || !strings.Contains(string(certBytes), "BEGIN CERTIFICATE") {
+			t.Fatalf("Expected the certificate of %s, got %d certificates and %q", file, len(cert.Certificate), certBytes)
+		}
+	}
+
+	config := &x509config.X509Config{Pkcs12Path: "testdata/client-aes.p12"}
+	if _, _, err := m.loadPkcs12(config); err == nil || !strings.Contains(err.Error(), "password protected") {
+		t.Fatalf("Expected a bundle without a passphrase to fail, got %v", err)
+	}
+}
+
+func TestResolvePassphrase(t *testing.T) {
+	t.Setenv("SPIN_TEST_PASSPHRASE", "from env")
+	ui, err := util.NewUI(false, util.ColorNever, "", strings.NewReader("from prompt\n"), ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("Could not create UI: %v", err)
+	}
+	m := &GatewayClient{UI: ui}
+
+	for _, test := range []struct {
+		passphrase x509config.PassphraseConfig
+		expected   string
+	}{
+		{x509config.PassphraseConfig{Env: "SPIN_TEST_PASSPHRASE"}, "from env"},
+		{x509config.PassphraseConfig{Command: "printf 'from command\\n'"}, "from command"},
+		{x509config.PassphraseConfig{Prompt: true}, "from prompt"},
+	} {
+		passphrase, err := m.resolvePassphrase(&test.passphrase)
+		if err != nil {
+			t.Fatalf("Could not resolve %+v: %v", test.passphrase, err)
+		}
+		if passphrase != test.expected {
+			t.Fatalf("Expected %q from %+v, got %q", test.expected, test.passphrase, passphrase)
+		}
+	}
+
+	for _, passphrase := range []x509config.PassphraseConfig{{Env: "SPIN_TEST_UNSET"}, {Command: "echo oops >&2; exit 1"}} {
+		if _, err := m.resolvePassphrase(&passphrase); err == nil {
+			t.Fatalf("Expected %+v to fail", passphrase)
+		}
+	}
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	content, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Could not read %s: %v", name, err)
+	}
+	return content
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package graph builds the stage graph of a pipeline or execution from its
+// stages' refIds and requisiteStageRefIds, and renders it as Graphviz DOT,
+// Mermaid or an ASCII tree.
+package graph
+
+import (
+	"fmt"
+)
+
+// Node is a stage in the graph.
+type Node struct {
+	RefId string
+	Name  string
+	Type  string
+	// Status is the stage's execution status, when graphing an execution.
+	Status string
+	// Requisites are the refIds of the stages that run before this one.
+	Requisites []string
+}
+
+// Graph is a pipeline's stages, in the order they are defined.
+type Graph struct {
+	Name  string
+	Nodes []*Node
+}
+
+// New builds the graph of a pipeline config or execution. Synthetic stages
+// that Orca adds to executions are left out.
+func New(pipeline map[string]interface{}) *Graph {
+	g := &Graph{}
+	g.Name, _ = pipeline["name"].(string)
+	stages, _ := pipeline["stages"].([]interface{})
+	for i, s := range stages {
+		stage, ok := s.(map[string]interface{})
+		if !ok || stage["syntheticStageOwner"] != nil {
+			continue
+		}
+		node := &Node{RefId: refString(stage["refId"])}
+		if node.RefId == "" {
+			node.RefId = fmt.Sprintf("stage-%d", i)
+		}
+		node.Name, _ = stage["name"].(string)
+		node.Type, _ = stage["type"].(string)
+		node.Status, _ = stage["status"].(string)
+		requisites, _ := stage["requisiteStageRefIds"].([]interface{})
+		for _, r := range requisites {
+			if requisite := refString(r); requisite != "" {
+				node.Requisites = append(node.Requisites, requisite)
+			}
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	return g
+}
+
+// Node returns the node with the refId, or nil.
+func (g *Graph) Node(refId string) *Node {
+	for _, node := range g.Nodes {
+		if node.RefId == refId {
+			return node
+		}
+	}
+	return nil
+}
+
+// Children returns the nodes that require the node, in definition order.
+func (g *Graph) Children(refId string) []*Node {
+	children := []*Node{}
+	for _, node := range g.Nodes {
+		for _, requisite := range node.Requisites {
+			if requisite == refId {
+				children = append(children, node)
+				break
+			}
+		}
+	}
+	return children
+}
+
+// Roots returns the nodes that have no requisite stages in the graph.
+func (g *Graph) Roots() []*Node {
+	roots := []*Node{}
+	for _, node := range g.Nodes {
+		root := true
+		for _, requisite := range node.Requisites {
+			if g.Node(requisite) != nil {
+				root = false
+				break
+			}
+		}
+		if root {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+// Label describes the node by its name, falling back to its type and refId.
+func (n *Node) Label() string {
+	switch {
+	case n.Name != "":
+		return n.Name
+	case n.Type != "":
+		return n.Type
+	default:
+		return n.RefId
+	}
+}
+
+func refString(v interface{}) string {
+	switch r := v.(type) {
+	case string:
+		return r
+	case float64:
+		return fmt.Sprint(r)
+	}
+	return ""
+}
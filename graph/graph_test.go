@@ -0,0 +1,104 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// diamondPipeline bakes, deploys to two regions, then verifies.
+const diamondPipeline = `{
+  "name": "deploy",
+  "stages": [
+    {"refId": "1", "name": "Bake", "type": "bake", "status": "SUCCEEDED"},
+    {"refId": "2", "name": "Deploy east", "type": "deploy", "requisiteStageRefIds": ["1"], "status": "RUNNING"},
+    {"refId": "3", "name": "Deploy \"west\"", "type": "deploy", "requisiteStageRefIds": ["1"], "status": "TERMINAL"},
+    {"refId": "4", "type": "manualJudgment", "requisiteStageRefIds": ["2", "3"]},
+    {"refId": "5", "name": "Synthetic", "type": "wait", "syntheticStageOwner": "STAGE_BEFORE"}
+  ]
+}`
+
+func testGraph(t *testing.T) *Graph {
+	var pipeline map[string]interface{}
+	if err := json.Unmarshal([]byte(diamondPipeline), &pipeline); err != nil {
+		t.Fatalf("Bad test pipeline: %v", err)
+	}
+	return New(pipeline)
+}
+
+func TestASCII(t *testing.T) {
+	expected := `[1] Bake (bake) SUCCEEDED
+├── [2] Deploy east (deploy) RUNNING
+│   └── [4] manualJudgment
+└── [3] Deploy "west" (deploy) TERMINAL
+    └── [4] manualJudgment (see above)
+`
+	if actual := ASCII(testGraph(t), nil); actual != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	expected := `digraph "deploy" {
+  rankdir=LR;
+  node [shape=box, style="rounded,filled", fillcolor="#ffffff"];
+  "1" [label="Bake\nbake\nSUCCEEDED", fillcolor="#b7e4b4"];
+  "2" [label="Deploy east\ndeploy\nRUNNING", fillcolor="#a8d8f0"];
+  "3" [label="Deploy \"west\"\ndeploy\nTERMINAL", fillcolor="#f4a6a6"];
+  "4" [label="manualJudgment"];
+  "1" -> "2";
+  "1" -> "3";
+  "2" -> "4";
+  "3" -> "4";
+}
+`
+	if actual := DOT(testGraph(t)); actual != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	expected := `graph LR
+  s0["Bake<br/>bake<br/>SUCCEEDED"]
+  s1["Deploy east<br/>deploy<br/>RUNNING"]
+  s2["Deploy #quot;west#quot;<br/>deploy<br/>TERMINAL"]
+  s3["manualJudgment"]
+  s0 --> s1
+  s0 --> s2
+  s1 --> s3
+  s2 --> s3
+  style s0 fill:#b7e4b4
+  style s1 fill:#a8d8f0
+  style s2 fill:#f4a6a6
+`
+	if actual := Mermaid(testGraph(t)); actual != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestASCII_cycle(t *testing.T) {
+	g := &Graph{Nodes: []*Node{
+		{RefId: "1", Type: "wait", Requisites: []string{"2"}},
+		{RefId: "2", Type: "wait", Requisites: []string{"1"}},
+	}}
+	expected := `[1] wait
+└── [2] wait
+    └── [1] wait (see above)
+`
+	if actual := ASCII(g, nil); actual != expected {
+		t.Fatalf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// statusFills colors DOT and Mermaid nodes by execution status.
+var statusFills = map[string]string{
+	"SUCCEEDED":       "#b7e4b4",
+	"RUNNING":         "#a8d8f0",
+	"PAUSED":          "#f7e3a1",
+	"SUSPENDED":       "#f7e3a1",
+	"FAILED_CONTINUE": "#f7e3a1",
+	"TERMINAL":        "#f4a6a6",
+	"CANCELED":        "#d9d9d9",
+	"STOPPED":         "#d9d9d9",
+	"SKIPPED":         "#d9d9d9",
+	"NOT_STARTED":     "#ffffff",
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func DOT(g *Graph) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.Name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];\n")
+	for _, node := range g.Nodes {
+		attributes := fmt.Sprintf("label=%s", dotQuote(nodeLabel(node, "\n")))
+		if fill, ok := statusFills[node.Status]; ok {
+			attributes += fmt.Sprintf(", fillcolor=%s", dotQuote(fill))
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.RefId), attributes)
+	}
+	for _, node := range g.Nodes {
+		for _, requisite := range node.Requisites {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(requisite), dotQuote(node.RefId))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, for Markdown such as PR
+// comments.
+func Mermaid(g *Graph) string {
+	ids := map[string]string{}
+	for i, node := range g.Nodes {
+		ids[node.RefId] = fmt.Sprintf("s%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node.RefId], mermaidEscape(nodeLabel(node, "<br/>")))
+	}
+	for _, node := range g.Nodes {
+		for _, requisite := range node.Requisites {
+			if id, ok := ids[requisite]; ok {
+				fmt.Fprintf(&b, "  %s --> %s\n", id, ids[node.RefId])
+			}
+		}
+	}
+	for _, node := range g.Nodes {
+		if fill, ok := statusFills[node.Status]; ok {
+			fmt.Fprintf(&b, "  style %s fill:%s\n", ids[node.RefId], fill)
+		}
+	}
+	return b.String()
+}
+
+// ASCII renders the graph as a tree for terminals, each stage under the
+// stages it requires. A stage with several requisites is drawn in full under
+// the first and marked "see above" under the rest. colorStatus, if not nil,
+// decorates statuses.
+func ASCII(g *Graph, colorStatus func(string) string) string {
+	if colorStatus == nil {
+		colorStatus = func(status string) string { return status }
+	}
+	var b strings.Builder
+	drawn := map[string]bool{}
+	var draw func(node *Node, prefix, branch, indent string)
+	draw = func(node *Node, prefix, branch, indent string) {
+		line := fmt.Sprintf("[%s] %s", node.RefId, node.Label())
+		if node.Type != "" && node.Type != node.Label() {
+			line += fmt.Sprintf(" (%s)", node.Type)
+		}
+		if node.Status != "" {
+			line += " " + colorStatus(node.Status)
+		}
+		if drawn[node.RefId] {
+			fmt.Fprintf(&b, "%s%s%s (see above)\n", prefix, branch, line)
+			return
+		}
+		drawn[node.RefId] = true
+		fmt.Fprintf(&b, "%s%s%s\n", prefix, branch, line)
+
+		children := g.Children(node.RefId)
+		for i, child := range children {
+			if i == len(children)-1 {
+				draw(child, prefix+indent, "└── ", "    ")
+			} else {
+				draw(child, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+
+	for _, root := range g.Roots() {
+		draw(root, "", "", "")
+	}
+	// Stages in a cycle have no root to be drawn from.
+	for _, node := range g.Nodes {
+		if !drawn[node.RefId] {
+			draw(node, "", "", "")
+		}
+	}
+	return b.String()
+}
+
+func nodeLabel(node *Node, separator string) string {
+	parts := []string{node.Label()}
+	if node.Type != "" && node.Type != node.Label() {
+		parts = append(parts, node.Type)
+	}
+	if node.Status != "" {
+		parts = append(parts, node.Status)
+	}
+	return strings.Join(parts, separator)
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package lint checks pipeline configs offline, without talking to Gate.
+// Each aspect of a pipeline is checked by a Rule; the built-in rules are
+// registered by default and tools can Register their own.
+//
+//	for _, finding := range lint.Lint(pipeline) {
+//		fmt.Println(finding)
+//	}
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Severities of a Finding. Errors make a pipeline invalid; warnings point
+// out likely mistakes.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is a problem found in a pipeline.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	// Path locates the problem in the pipeline, such as stages[1].refId.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	if f.Path == "" {
+		return f.Message
+	}
+	return fmt.Sprintf("%s: %s", f.Path, f.Message)
+}
+
+// Rule checks one aspect of a pipeline.
+type Rule interface {
+	// Name identifies the rule in findings and when disabling it.
+	Name() string
+	Check(pipeline map[string]interface{}) []Finding
+}
+
+// RuleFunc adapts a function to a Rule.
+type RuleFunc struct {
+	RuleName string
+	Func     func(pipeline map[string]interface{}) []Finding
+}
+
+func (r RuleFunc) Name() string {
+	return r.RuleName
+}
+
+func (r RuleFunc) Check(pipeline map[string]interface{}) []Finding {
+	return r.Func(pipeline)
+}
+
+var (
+	registryLock sync.Mutex
+	registry     = []Rule{}
+)
+
+// Register adds a rule to those run by Lint.
+func Register(rule Rule) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry = append(registry, rule)
+}
+
+// Rules returns the registered rules.
+func Rules() []Rule {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	return append([]Rule{}, registry...)
+}
+
+// Lint checks the pipeline with the registered rules, skipping any named in
+// disabled. Findings are ordered by path, then rule. The pipeline is not
+// modified.
+func Lint(pipeline map[string]interface{}, disabled ...string) []Finding {
+	skip := map[string]bool{}
+	for _, name := range disabled {
+		skip[name] = true
+	}
+	findings := []Finding{}
+	for _, rule := range Rules() {
+		if skip[rule.Name()] {
+			continue
+		}
+		for _, finding := range rule.Check(pipeline) {
+			finding.Rule = rule.Name()
+			if finding.Severity == "" {
+				finding.Severity = SeverityError
+			}
+			findings = append(findings, finding)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+	return findings
+}
+
+// Errors returns the findings with error severity.
+func Errors(findings []Finding) []Finding {
+	errors := []Finding{}
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			errors = append(errors, finding)
+		}
+	}
+	return errors
+}
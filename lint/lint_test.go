@@ -0,0 +1,95 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package lint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline string
+		expected []string
+	}{
+		{
+			name:     "valid",
+			pipeline: `{"application": "app", "name": "p", "stages": [{"refId": "1", "type": "wait"}, {"refId": "2", "type": "wait", "requisiteStageRefIds": ["1"]}]}`,
+			expected: []string{},
+		},
+		{
+			name:     "missing keys",
+			pipeline: `{"template": {"source": "x"}}`,
+			expected: []string{"error Required pipeline key 'name' missing", "error Required pipeline key 'application' missing", "error Required pipeline key 'schema' missing for templated pipeline"},
+		},
+		{
+			name:     "stage graph",
+			pipeline: `{"application": "app", "name": "p", "stages": [{"refId": "1", "type": "wait", "requisiteStageRefIds": ["3"]}, {"refId": "1", "type": "wait"}, {"type": "wait", "requisiteStageRefIds": ["9"]}]}`,
+			expected: []string{"error stages[0].requisiteStageRefIds[0]: no stage has refId 3", "error stages[1].refId: refId 1 is used by more than one stage", "error stages[2].refId: stage has no refId", "error stages[2].requisiteStageRefIds[0]: no stage has refId 9"},
+		},
+		{
+			name:     "cycle",
+			pipeline: `{"application": "app", "name": "p", "stages": [{"refId": "1", "type": "wait", "requisiteStageRefIds": ["3"]}, {"refId": "2", "type": "wait", "requisiteStageRefIds": ["1"]}, {"refId": "3", "type": "wait", "requisiteStageRefIds": ["2"]}]}`,
+			expected: []string{"error stages: stages require each other in a cycle: 1 -> 3 -> 2 -> 1"},
+		},
+		{
+			name:     "stage types",
+			pipeline: `{"application": "app", "name": "p", "stages": [{"refId": "1"}, {"refId": "2", "type": "myWebhook"}]}`,
+			expected: []string{"error stages[0].type: stage has no type", "warning stages[1].type: myWebhook is not a built-in stage type; check it is a custom or plugin stage"},
+		},
+		{
+			name:     "triggers",
+			pipeline: `{"application": "app", "name": "p", "triggers": [{"type": "cron"}, {"type": "git", "source": "github", "project": "spinnaker", "slug": "spin"}, {}]}`,
+			expected: []string{"error triggers[0].cronExpression: cron trigger requires cronExpression", "error triggers[2].type: trigger has no type"},
+		},
+		{
+			name:     "parameters",
+			pipeline: `{"application": "app", "name": "p", "parameterConfig": [{"name": "env", "hasOptions": true, "default": "qa", "options": [{"value": "prod"}]}, {"name": "env"}, {}]}`,
+			expected: []string{"warning parameterConfig[0].default: default qa is not one of the parameter's options", "error parameterConfig[1].name: parameter env is defined more than once", "error parameterConfig[2].name: parameter has no name"},
+		},
+		{
+			name:     "expected artifacts",
+			pipeline: `{"application": "app", "name": "p", "expectedArtifacts": [{"id": "a1"}], "triggers": [{"type": "webhook", "expectedArtifactIds": ["a1", "a2"]}], "stages": [{"refId": "1", "type": "deployManifest", "manifestArtifactId": "a3"}]}`,
+			expected: []string{"error stages[0].manifestArtifactId: no expected artifact has id a3", "error triggers[0].expectedArtifactIds[1]: no expected artifact has id a2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var pipeline map[string]interface{}
+			if err := json.Unmarshal([]byte(test.pipeline), &pipeline); err != nil {
+				t.Fatalf("Bad test pipeline: %v", err)
+			}
+			actual := []string{}
+			for _, finding := range Lint(pipeline) {
+				actual = append(actual, finding.Severity+" "+finding.String())
+			}
+			if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+				t.Fatalf("Expected findings:\n%s\ngot:\n%s", strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
+
+func TestLint_disable(t *testing.T) {
+	pipeline := map[string]interface{}{"application": "app", "name": "p", "stages": []interface{}{
+		map[string]interface{}{"refId": "1", "type": "myWebhook"},
+	}}
+	if findings := Lint(pipeline, "stage-types"); len(findings) != 0 {
+		t.Fatalf("Expected the disabled rule to be skipped, got %v", findings)
+	}
+}
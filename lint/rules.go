@@ -0,0 +1,324 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// knownStageTypes are the stage types built into Spinnaker. Other types,
+// such as preconfigured webhooks and plugin stages, are reported as warnings.
+var knownStageTypes = stringSet(
+	"bake", "bakeManifest", "canary", "checkPreconditions", "cloneServerGroup", "concourse",
+	"createServerGroup", "deleteManifest", "deploy", "deployCloudFormation", "deployManifest",
+	"destroyServerGroup", "disableCluster", "disableServerGroup", "enableServerGroup",
+	"evaluateVariables", "findArtifactFromExecution", "findArtifactsFromResource", "findImage",
+	"findImageFromTags", "gcb", "jenkins", "kayentaCanary", "manualJudgment", "patchManifest",
+	"pipeline", "resizeServerGroup", "rollbackCluster", "runJob", "runJobManifest",
+	"scaleDownCluster", "scaleManifest", "script", "shrinkCluster", "travis",
+	"undoRolloutManifest", "wait", "webhook", "wercker",
+)
+
+// triggerRequiredKeys are the keys each built-in trigger type needs.
+var triggerRequiredKeys = map[string][]string{
+	"artifactory": {"artifactorySearchName"},
+	"concourse":   {"master", "team", "project", "jobName"},
+	"cron":        {"cronExpression"},
+	"docker":      {"account", "repository"},
+	"git":         {"source", "project", "slug"},
+	"helm":        {"account", "chart"},
+	"jenkins":     {"master", "job"},
+	"nexus":       {"nexusSearchName"},
+	"pipeline":    {"application", "pipeline"},
+	"plugin":      {},
+	"pubsub":      {"pubsubSystem", "subscriptionName"},
+	"travis":      {"master", "job"},
+	"webhook":     {},
+	"wercker":     {"master", "app", "pipeline"},
+}
+
+func init() {
+	Register(RuleFunc{"required-keys", checkRequiredKeys})
+	Register(RuleFunc{"stage-refs", checkStageRefs})
+	Register(RuleFunc{"stage-cycles", checkStageCycles})
+	Register(RuleFunc{"stage-types", checkStageTypes})
+	Register(RuleFunc{"triggers", checkTriggers})
+	Register(RuleFunc{"parameters", checkParameters})
+	Register(RuleFunc{"expected-artifacts", checkExpectedArtifacts})
+}
+
+func checkRequiredKeys(pipeline map[string]interface{}) []Finding {
+	findings := []Finding{}
+	for _, key := range []string{"name", "application"} {
+		if _, exists := pipeline[key]; !exists {
+			findings = append(findings, Finding{Message: fmt.Sprintf("Required pipeline key '%s' missing", key)})
+		}
+	}
+	if template, ok := pipeline["template"].(map[string]interface{}); ok && len(template) > 0 {
+		if _, exists := pipeline["schema"]; !exists {
+			findings = append(findings, Finding{Message: "Required pipeline key 'schema' missing for templated pipeline"})
+		}
+	}
+	return findings
+}
+
+// checkStageRefs checks that every stage has a unique refId and that
+// requisiteStageRefIds name other stages.
+func checkStageRefs(pipeline map[string]interface{}) []Finding {
+	findings := []Finding{}
+	seen := map[string]bool{}
+	each(pipeline, "stages", &findings, func(path string, stage map[string]interface{}) {
+		refId, ok := ref(stage["refId"])
+		switch {
+		case !ok:
+			findings = append(findings, Finding{Path: path + ".refId", Message: "stage has no refId"})
+		case seen[refId]:
+			findings = append(findings, Finding{Path: path + ".refId", Message: fmt.Sprintf("refId %s is used by more than one stage", refId)})
+		default:
+			seen[refId] = true
+		}
+	})
+	each(pipeline, "stages", nil, func(path string, stage map[string]interface{}) {
+		refId, _ := ref(stage["refId"])
+		requisites, _ := stage["requisiteStageRefIds"].([]interface{})
+		for i, r := range requisites {
+			requisite, ok := ref(r)
+			requisitePath := fmt.Sprintf("%s.requisiteStageRefIds[%d]", path, i)
+			switch {
+			case !ok:
+				findings = append(findings, Finding{Path: requisitePath, Message: "must be a stage refId"})
+			case requisite == refId:
+				findings = append(findings, Finding{Path: requisitePath, Message: "stage depends on itself"})
+			case !seen[requisite]:
+				findings = append(findings, Finding{Path: requisitePath, Message: fmt.Sprintf("no stage has refId %s", requisite)})
+			}
+		}
+	})
+	return findings
+}
+
+// checkStageCycles reports stages that transitively depend on themselves.
+func checkStageCycles(pipeline map[string]interface{}) []Finding {
+	order := []string{}
+	requisites := map[string][]string{}
+	each(pipeline, "stages", nil, func(path string, stage map[string]interface{}) {
+		refId, ok := ref(stage["refId"])
+		if !ok {
+			return
+		}
+		order = append(order, refId)
+		list, _ := stage["requisiteStageRefIds"].([]interface{})
+		for _, r := range list {
+			if requisite, ok := ref(r); ok && requisite != refId {
+				requisites[refId] = append(requisites[refId], requisite)
+			}
+		}
+	})
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	findings := []Finding{}
+	state := map[string]int{}
+	stack := []string{}
+	var visit func(refId string)
+	visit = func(refId string) {
+		state[refId] = visiting
+		stack = append(stack, refId)
+		for _, requisite := range requisites[refId] {
+			switch state[requisite] {
+			case unvisited:
+				visit(requisite)
+			case visiting:
+				// The stack from the requisite onwards is the cycle, each stage
+				// requiring the next.
+				start := len(stack) - 1
+				for stack[start] != requisite {
+					start--
+				}
+				cycle := append(append([]string{}, stack[start:]...), requisite)
+				findings = append(findings, Finding{Path: "stages",
+					Message: fmt.Sprintf("stages require each other in a cycle: %s", strings.Join(cycle, " -> "))})
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[refId] = visited
+	}
+	for _, refId := range order {
+		if state[refId] == unvisited {
+			visit(refId)
+		}
+	}
+	return findings
+}
+
+func checkStageTypes(pipeline map[string]interface{}) []Finding {
+	findings := []Finding{}
+	each(pipeline, "stages", nil, func(path string, stage map[string]interface{}) {
+		stageType, _ := stage["type"].(string)
+		switch {
+		case stageType == "":
+			findings = append(findings, Finding{Path: path + ".type", Message: "stage has no type"})
+		case !knownStageTypes[stageType]:
+			findings = append(findings, Finding{Path: path + ".type", Severity: SeverityWarning,
+				Message: fmt.Sprintf("%s is not a built-in stage type; check it is a custom or plugin stage", stageType)})
+		}
+	})
+	return findings
+}
+
+func checkTriggers(pipeline map[string]interface{}) []Finding {
+	findings := []Finding{}
+	each(pipeline, "triggers", &findings, func(path string, trigger map[string]interface{}) {
+		triggerType, _ := trigger["type"].(string)
+		required, known := triggerRequiredKeys[triggerType]
+		switch {
+		case triggerType == "":
+			findings = append(findings, Finding{Path: path + ".type", Message: "trigger has no type"})
+			return
+		case !known:
+			findings = append(findings, Finding{Path: path + ".type", Severity: SeverityWarning,
+				Message: fmt.Sprintf("%s is not a built-in trigger type", triggerType)})
+			return
+		}
+		for _, key := range required {
+			if value, exists := trigger[key]; !exists || value == "" {
+				findings = append(findings, Finding{Path: path + "." + key, Message: fmt.Sprintf("%s trigger requires %s", triggerType, key)})
+			}
+		}
+	})
+	return findings
+}
+
+func checkParameters(pipeline map[string]interface{}) []Finding {
+	findings := []Finding{}
+	seen := map[string]bool{}
+	each(pipeline, "parameterConfig", &findings, func(path string, parameter map[string]interface{}) {
+		name, _ := parameter["name"].(string)
+		switch {
+		case name == "":
+			findings = append(findings, Finding{Path: path + ".name", Message: "parameter has no name"})
+			return
+		case seen[name]:
+			findings = append(findings, Finding{Path: path + ".name", Message: fmt.Sprintf("parameter %s is defined more than once", name)})
+		}
+		seen[name] = true
+
+		hasOptions, _ := parameter["hasOptions"].(bool)
+		options, _ := parameter["options"].([]interface{})
+		if !hasOptions || len(options) == 0 {
+			return
+		}
+		defaultValue, _ := parameter["default"].(string)
+		if defaultValue == "" {
+			return
+		}
+		for _, o := range options {
+			if option, ok := o.(map[string]interface{}); ok && fmt.Sprint(option["value"]) == defaultValue {
+				return
+			}
+		}
+		findings = append(findings, Finding{Path: path + ".default", Severity: SeverityWarning,
+			Message: fmt.Sprintf("default %s is not one of the parameter's options", defaultValue)})
+	})
+	return findings
+}
+
+// checkExpectedArtifacts checks that expected artifacts have unique ids and
+// that triggers and stages only reference declared artifacts.
+func checkExpectedArtifacts(pipeline map[string]interface{}) []Finding {
+	findings := []Finding{}
+	declared := map[string]bool{}
+	each(pipeline, "expectedArtifacts", &findings, func(path string, artifact map[string]interface{}) {
+		id, _ := artifact["id"].(string)
+		switch {
+		case id == "":
+			findings = append(findings, Finding{Path: path + ".id", Message: "expected artifact has no id"})
+		case declared[id]:
+			findings = append(findings, Finding{Path: path + ".id", Message: fmt.Sprintf("expected artifact id %s is used more than once", id)})
+		}
+		declared[id] = true
+	})
+
+	checkIds := func(path string, ids interface{}) {
+		list, _ := ids.([]interface{})
+		for i, id := range list {
+			if !declared[fmt.Sprint(id)] {
+				findings = append(findings, Finding{Path: fmt.Sprintf("%s[%d]", path, i),
+					Message: fmt.Sprintf("no expected artifact has id %v", id)})
+			}
+		}
+	}
+	each(pipeline, "triggers", nil, func(path string, trigger map[string]interface{}) {
+		checkIds(path+".expectedArtifactIds", trigger["expectedArtifactIds"])
+	})
+	each(pipeline, "stages", nil, func(path string, stage map[string]interface{}) {
+		checkIds(path+".requiredArtifactIds", stage["requiredArtifactIds"])
+		if id, ok := stage["manifestArtifactId"].(string); ok && id != "" && !declared[id] {
+			findings = append(findings, Finding{Path: path + ".manifestArtifactId",
+				Message: fmt.Sprintf("no expected artifact has id %s", id)})
+		}
+	})
+	return findings
+}
+
+// each calls fn with the path of each object in the pipeline's list at key.
+// Entries that are not objects are reported in findings, when it is not nil.
+func each(pipeline map[string]interface{}, key string, findings *[]Finding, fn func(path string, item map[string]interface{})) {
+	value, exists := pipeline[key]
+	if !exists || value == nil {
+		return
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		if findings != nil {
+			*findings = append(*findings, Finding{Path: key, Message: fmt.Sprintf("%s must be a list", key)})
+		}
+		return
+	}
+	for i, v := range list {
+		path := fmt.Sprintf("%s[%d]", key, i)
+		item, ok := v.(map[string]interface{})
+		if !ok {
+			if findings != nil {
+				*findings = append(*findings, Finding{Path: path, Message: "must be an object"})
+			}
+			continue
+		}
+		fn(path, item)
+	}
+}
+
+// ref returns a refId, which Spinnaker accepts as a string or a number.
+func ref(v interface{}) (string, bool) {
+	switch r := v.(type) {
+	case string:
+		return r, r != ""
+	case float64:
+		return fmt.Sprint(r), true
+	}
+	return "", false
+}
+
+func stringSet(values ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -15,15 +15,11 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/spinnaker/spin/cmd"
 )
 
 func main() {
-	if err := cmd.Execute(os.Stdout); err != nil {
-		fmt.Fprintf(os.Stderr, "\n%v\n", err)
-		os.Exit(1)
-	}
+	os.Exit(cmd.Execute(os.Stdout))
 }
@@ -19,92 +19,122 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/mitchellh/cli"
 	"github.com/mitchellh/colorstring"
-	"github.com/spinnaker/spin/cmd/output"
 	"k8s.io/client-go/util/jsonpath"
 )
 
+// ColorizeUi writes command results to its Writer and diagnostics, such as
+// progress, warnings and prompts, to the Ui's error output, so results can
+// be piped while messages still reach the terminal.
 type ColorizeUi struct {
-	Colorize     *colorstring.Colorize
-	OutputColor  string
-	InfoColor    string
-	ErrorColor   string
-	WarnColor    string
-	Ui           cli.Ui
-	Quiet        bool
-	OutputFormat *output.OutputFormat
-}
-
-var UI ColorizeUi
-var hasColor bool
-
-func InitUI(quiet, color bool, outputFormat string) {
-	UI = ColorizeUi{
-		Colorize:   Colorize(),
-		ErrorColor: "[red]",
-		WarnColor:  "[yellow]",
-		InfoColor:  "[blue]",
-		Ui:         &cli.BasicUi{Writer: os.Stdout},
-		Quiet:      quiet,
-	}
-	var err error
-	hasColor = color
-	UI.OutputFormat, err = output.ParseOutputFormat(outputFormat)
+	// Colorize colors diagnostics, OutputColorize colors results; each is
+	// enabled when its stream supports color.
+	Colorize       *colorstring.Colorize
+	OutputColorize *colorstring.Colorize
+	OutputColor    string
+	InfoColor      string
+	ErrorColor     string
+	WarnColor      string
+	Ui             cli.Ui
+	Quiet          bool
+	OutputFormat   *OutputFormat
+
+	// Writer receives command results.
+	Writer io.Writer
+
+	// ErrorWriter receives diagnostics that aren't messages, such as request
+	// traces. Defaults to stderr.
+	ErrorWriter io.Writer
+}
+
+// NewUI creates a UI reading answers from in, writing results to out and
+// diagnostics to errOut. color is one of ColorAuto, ColorAlways or ColorNever.
+func NewUI(quiet bool, color string, outputFormat string, in io.Reader, out, errOut io.Writer) (*ColorizeUi, error) {
+	format, err := ParseOutputFormat(outputFormat)
+	if err != nil {
+		return nil, NewUsageError("%v", err)
+	}
+	outColor, err := ColorEnabled(color, out)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	errColor, err := ColorEnabled(color, errOut)
+	if err != nil {
+		return nil, err
+	}
+	return &ColorizeUi{
+		Colorize:       newColorize(errColor),
+		OutputColorize: newColorize(outColor),
+		ErrorColor:     "[red]",
+		WarnColor:      "[yellow]",
+		InfoColor:      "[blue]",
+		Ui:             &cli.BasicUi{Reader: in, Writer: errOut, ErrorWriter: errOut},
+		Quiet:          quiet,
+		OutputFormat:   format,
+		Writer:         out,
+		ErrorWriter:    errOut,
+	}, nil
 }
 
-// Colorize initializes the ui colorization.
-func Colorize() *colorstring.Colorize {
-	return &colorstring.Colorize{
-		Colors:  colorstring.DefaultColors,
-		Disable: !hasColor,
-		Reset:   true,
+// DiagnosticWriter returns the writer for diagnostics such as request traces.
+func (u *ColorizeUi) DiagnosticWriter() io.Writer {
+	if u.ErrorWriter == nil {
+		return os.Stderr
 	}
+	return u.ErrorWriter
 }
 
 func (u *ColorizeUi) Ask(query string) (string, error) {
-	return u.Ui.Ask(u.colorize(query, u.OutputColor))
+	return u.Ui.Ask(query)
 }
 
 func (u *ColorizeUi) AskSecret(query string) (string, error) {
-	return u.Ui.AskSecret(u.colorize(query, u.OutputColor))
+	return u.Ui.AskSecret(query)
 }
 
+// Output writes a command result.
 func (u *ColorizeUi) Output(message string) {
-	u.Ui.Output(u.colorize(message, u.OutputColor))
+	if u.OutputColor != "" {
+		message = u.OutputColorize.Color(u.OutputColor + message)
+	}
+	fmt.Fprintln(u.Writer, message)
+}
+
+// Status colors an execution or task status, such as SUCCEEDED or
+// TERMINAL, for use in diagnostics.
+func (u *ColorizeUi) Status(status string) string {
+	return colorStatus(u.Colorize, status)
+}
+
+// OutputStatus colors a status for use in command results, such as a table column.
+func (u *ColorizeUi) OutputStatus(status string) string {
+	return colorStatus(u.OutputColorize, status)
 }
 
 // JsonOutput pretty prints the data specified in the input.
-// Callers can optionally supply a jsonpath template to pull out nested data in input.
+// Callers can optionally supply a jsonpath template with --output to pull out nested data in input.
 // This leverages the kubernetes jsonpath libs (https://kubernetes.io/docs/reference/kubectl/jsonpath/).
-func (u *ColorizeUi) JsonOutput(input interface{}, outputFormat *output.OutputFormat) {
-	if outputFormat == nil {
-		prettyStr, _ := json.MarshalIndent(input, "", " ")
-		u.Output(u.colorize(string(prettyStr), u.OutputColor))
-		return
-	}
-
-	template := outputFormat.JsonPath
-	if template == "" {
-		prettyStr, _ := json.MarshalIndent(input, "", " ")
-		u.Output(u.colorize(string(prettyStr), u.OutputColor))
-	} else {
-		jsonValue, err := u.parseJsonPath(input, template)
+func (u *ColorizeUi) JsonOutput(input interface{}) error {
+	if u.OutputFormat != nil && u.OutputFormat.JsonPath != "" {
+		jsonValue, err := u.parseJsonPath(input, u.OutputFormat.JsonPath)
 		if err != nil {
-			u.Error(fmt.Sprintf("%v", err))
+			return NewUsageError("%v", err)
 		}
+		input = jsonValue
+	}
 
-		prettyStr, err := json.MarshalIndent(jsonValue, "", " ")
-		if err != nil {
-			u.Error(fmt.Sprintf("%v", err))
-		}
-		u.Output(u.colorize(string(prettyStr), u.OutputColor))
+	prettyStr, err := json.MarshalIndent(input, "", " ")
+	if err != nil {
+		return err
 	}
+	u.Output(string(prettyStr))
+	return nil
 }
 
 // parseJsonPath finds the values specified in the input data as specified with the template.
@@ -126,12 +156,46 @@ func (u *ColorizeUi) parseJsonPath(input interface{}, template string) (interfac
 	return nil, errors.New(fmt.Sprintf("Error parsing value from input %v using template %s: %v ", input, template, err))
 }
 
+// Table writes rows aligned in columns under the headers, as a command result.
+func (u *ColorizeUi) Table(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(u.Writer, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// Diff writes a diff to the diagnostic output, coloring added, removed and
+// changed lines.
+func (u *ColorizeUi) Diff(diff string) {
+	if u.Quiet {
+		return
+	}
+	for _, line := range colorDiff(u.Colorize, diff) {
+		u.Ui.Info(line)
+	}
+}
+
+// OutputDiff writes a diff as a command result.
+func (u *ColorizeUi) OutputDiff(diff string) {
+	for _, line := range colorDiff(u.OutputColorize, diff) {
+		fmt.Fprintln(u.Writer, line)
+	}
+}
+
+// Info reports progress on the diagnostic output.
 func (u *ColorizeUi) Info(message string) {
 	if !u.Quiet {
 		u.Ui.Info(u.colorize(message, u.InfoColor))
 	}
 }
 
+// Success reports that an operation completed.
+func (u *ColorizeUi) Success(message string) {
+	u.Info("[reset][bold][green]" + message)
+}
+
 func (u *ColorizeUi) Error(message string) {
 	u.Ui.Error(u.colorize(message, u.ErrorColor))
 }
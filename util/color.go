@@ -0,0 +1,122 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mitchellh/colorstring"
+)
+
+// Values of the --color flag.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// statusColors colors Spinnaker execution and task statuses.
+var statusColors = map[string]string{
+	"SUCCEEDED":       "[green]",
+	"RUNNING":         "[cyan]",
+	"PAUSED":          "[yellow]",
+	"SUSPENDED":       "[yellow]",
+	"FAILED_CONTINUE": "[yellow]",
+	"TERMINAL":        "[red]",
+	"CANCELED":        "[dark_gray]",
+	"STOPPED":         "[dark_gray]",
+	"SKIPPED":         "[dark_gray]",
+	"NOT_STARTED":     "[dark_gray]",
+	"BUFFERED":        "[dark_gray]",
+}
+
+// ColorEnabled decides whether output written to w should be colored. In
+// auto mode color is used on terminals, unless NO_COLOR is set
+// (https://no-color.org) or forced on with CLICOLOR_FORCE.
+func ColorEnabled(mode string, w io.Writer) (bool, error) {
+	switch mode {
+	case ColorNever:
+		return false, nil
+	case ColorAlways:
+		return true, nil
+	case ColorAuto, "":
+	default:
+		return false, NewUsageError("--color must be one of auto, always or never, got %q", mode)
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false, nil
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true, nil
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false, nil
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false, nil
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()), nil
+}
+
+func newColorize(enabled bool) *colorstring.Colorize {
+	return &colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Disable: !enabled,
+		Reset:   true,
+	}
+}
+
+// colorLine colors all of line without interpreting color codes within it.
+func colorLine(colorize *colorstring.Colorize, color, line string) string {
+	return fmt.Sprintf(colorize.Color(color+"%s"), line)
+}
+
+// colorDiff splits a unified diff, or JsonChange lines, and colors each line
+// by its prefix.
+func colorDiff(colorize *colorstring.Colorize, diff string) []string {
+	if diff == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = colorLine(colorize, "[bold]", line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = colorLine(colorize, "[green]", line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = colorLine(colorize, "[red]", line)
+		case strings.HasPrefix(line, "~"):
+			lines[i] = colorLine(colorize, "[yellow]", line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = colorLine(colorize, "[cyan]", line)
+		}
+	}
+	return lines
+}
+
+func colorStatus(colorize *colorstring.Colorize, status string) string {
+	color, ok := statusColors[status]
+	if !ok {
+		return status
+	}
+	return colorize.Color(color + status)
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// JsonDiff returns a unified diff between the indented JSON of from and to,
+// or an empty string if they are equal. A nil from or to diffs against
+// nothing, as when a resource is created or deleted.
+func JsonDiff(from, to interface{}, fromName, toName string) (string, error) {
+	fromLines, err := jsonLines(from)
+	if err != nil {
+		return "", err
+	}
+	toLines, err := jsonLines(to)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        fromLines,
+		B:        toLines,
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+func jsonLines(v interface{}) ([]string, error) {
+	if v == nil {
+		return []string{}, nil
+	}
+	// Map keys are sorted when marshalled, so equal values diff cleanly.
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return difflib.SplitLines(string(b) + "\n"), nil
+}
+
+// Kinds of JsonChange.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// JsonChange is a single difference between two JSON documents.
+type JsonChange struct {
+	Kind string      `json:"kind"`
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// MarshalJSON leaves "from" out of added changes and "to" out of removed
+// ones. Other values are kept even when they are false, "", 0 or null.
+func (c JsonChange) MarshalJSON() ([]byte, error) {
+	change := struct {
+		Kind string       `json:"kind"`
+		Path string       `json:"path"`
+		From *interface{} `json:"from,omitempty"`
+		To   *interface{} `json:"to,omitempty"`
+	}{Kind: c.Kind, Path: c.Path}
+	if c.Kind != ChangeAdded {
+		change.From = &c.From
+	}
+	if c.Kind != ChangeRemoved {
+		change.To = &c.To
+	}
+	return json.Marshal(change)
+}
+
+// String renders the change as a line prefixed with +, - or ~.
+func (c JsonChange) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, compactJson(c.To))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, compactJson(c.From))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, compactJson(c.From), compactJson(c.To))
+	}
+}
+
+// JsonChanges compares two decoded JSON documents key by key and element by
+// element, returning the differences ordered by path.
+func JsonChanges(from, to interface{}) []JsonChange {
+	changes := []JsonChange{}
+	collectChanges("", from, to, &changes)
+	return changes
+}
+
+func collectChanges(path string, from, to interface{}, changes *[]JsonChange) {
+	switch fromValue := from.(type) {
+	case map[string]interface{}:
+		toValue, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := []string{}
+		for k := range fromValue {
+			keys = append(keys, k)
+		}
+		for k := range toValue {
+			if _, exists := fromValue[k]; !exists {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := joinPath(path, k)
+			f, inFrom := fromValue[k]
+			t, inTo := toValue[k]
+			switch {
+			case !inFrom:
+				*changes = append(*changes, JsonChange{Kind: ChangeAdded, Path: childPath, To: t})
+			case !inTo:
+				*changes = append(*changes, JsonChange{Kind: ChangeRemoved, Path: childPath, From: f})
+			default:
+				collectChanges(childPath, f, t, changes)
+			}
+		}
+		return
+	case []interface{}:
+		toValue, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(fromValue) || i < len(toValue); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(fromValue):
+				*changes = append(*changes, JsonChange{Kind: ChangeAdded, Path: childPath, To: toValue[i]})
+			case i >= len(toValue):
+				*changes = append(*changes, JsonChange{Kind: ChangeRemoved, Path: childPath, From: fromValue[i]})
+			default:
+				collectChanges(childPath, fromValue[i], toValue[i], changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, JsonChange{Kind: ChangeChanged, Path: path, From: from, To: to})
+	}
+}
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func joinPath(path, key string) string {
+	if !identifierRe.MatchString(key) {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func compactJson(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
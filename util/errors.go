@@ -0,0 +1,163 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Exit codes returned by spin. These are part of the CLI's interface;
+// scripts may rely on them, so existing values must not change.
+const (
+	ExitOK       = 0 // The command succeeded.
+	ExitError    = 1 // An error not covered by a more specific code.
+	ExitUsage    = 2 // Invalid flags or arguments.
+	ExitInvalid  = 3 // The submitted input was rejected as invalid, locally or by Gate (400/422).
+	ExitAuth     = 4 // Authentication or authorization failed (401/403).
+	ExitNotFound = 5 // The requested resource does not exist (404).
+	ExitConflict = 6 // The request conflicts with the current server state (409).
+	ExitServer   = 7 // Gate or a downstream service failed (5xx).
+	ExitNetwork  = 8 // Gate could not be reached: connection failures and timeouts.
+
+	// ExitInterrupted follows the shell convention of 128 + SIGINT.
+	ExitInterrupted = 130 // The command was interrupted by SIGINT or SIGTERM.
+)
+
+var exitCodeKinds = map[int]string{
+	ExitError:    "error",
+	ExitUsage:    "usage",
+	ExitInvalid:  "invalid",
+	ExitAuth:     "unauthorized",
+	ExitNotFound: "not_found",
+	ExitConflict: "conflict",
+	ExitServer:   "server_error",
+	ExitNetwork:  "network",
+
+	ExitInterrupted: "interrupted",
+}
+
+// ExitCoder is implemented by errors that map to a specific exit code.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// ErrorDetailer is implemented by errors that carry structured details,
+// such as the Gate response, to include in JSON error output.
+type ErrorDetailer interface {
+	ErrorDetails() map[string]interface{}
+}
+
+// CodedError is an error with an explicit exit code.
+type CodedError struct {
+	Code int
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CodedError) ExitCode() int {
+	return e.Code
+}
+
+// NewUsageError reports invalid flags or arguments.
+func NewUsageError(format string, a ...interface{}) error {
+	return &CodedError{Code: ExitUsage, Err: fmt.Errorf(format, a...)}
+}
+
+// NewValidationError reports input that failed validation before it was sent.
+func NewValidationError(format string, a ...interface{}) error {
+	return &CodedError{Code: ExitInvalid, Err: fmt.Errorf(format, a...)}
+}
+
+// NewAuthError reports an operation the user isn't permitted to perform.
+func NewAuthError(format string, a ...interface{}) error {
+	return &CodedError{Code: ExitAuth, Err: fmt.Errorf(format, a...)}
+}
+
+// NewNotFoundError reports a resource that doesn't exist.
+func NewNotFoundError(format string, a ...interface{}) error {
+	return &CodedError{Code: ExitNotFound, Err: fmt.Errorf(format, a...)}
+}
+
+// NewConflictError reports a request that conflicts with existing resources.
+func NewConflictError(format string, a ...interface{}) error {
+	return &CodedError{Code: ExitConflict, Err: fmt.Errorf(format, a...)}
+}
+
+// NewDiffError reports that compared resources differ, for commands that
+// let scripts gate on drift.
+func NewDiffError(format string, a ...interface{}) error {
+	return &CodedError{Code: ExitError, Err: fmt.Errorf(format, a...)}
+}
+
+// NewInterruptedError reports a command that was stopped by a signal.
+func NewInterruptedError(format string, a ...interface{}) error {
+	return &CodedError{Code: ExitInterrupted, Err: fmt.Errorf(format, a...)}
+}
+
+// ExitCode returns the exit code for err.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	if errors.Is(err, context.Canceled) {
+		// The only source of cancellation is the signal handler in cmd.Execute.
+		return ExitInterrupted
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ExitNetwork
+	}
+	return ExitError
+}
+
+// WriteError reports err to w, either as text or as a JSON document of the form
+// {"error": {"message": ..., "exitCode": ..., "kind": ..., ...details}}.
+func WriteError(w io.Writer, err error, asJson bool) {
+	if !asJson {
+		fmt.Fprintf(w, "\n%v\n", err)
+		return
+	}
+
+	code := ExitCode(err)
+	body := map[string]interface{}{
+		"message":  err.Error(),
+		"exitCode": code,
+		"kind":     exitCodeKinds[code],
+	}
+	var detailer ErrorDetailer
+	if errors.As(err, &detailer) {
+		for k, v := range detailer.ErrorDetails() {
+			body[k] = v
+		}
+	}
+	buf, _ := json.MarshalIndent(map[string]interface{}{"error": body}, "", " ")
+	fmt.Fprintln(w, string(buf))
+}
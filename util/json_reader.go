@@ -15,21 +15,35 @@
 package util
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+
+	"gopkg.in/yaml.v2"
 )
 
 func ParseJsonFromFileOrStdin(filePath string) (map[string]interface{}, error) {
+	content, err := ReadFileOrStdin(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJson(content)
+}
+
+// ReadFileOrStdin reads the file, or piped stdin if filePath is empty.
+func ReadFileOrStdin(filePath string) ([]byte, error) {
 	var fromFile *os.File
 	var err error
-	var jsonContent map[string]interface{}
 
 	if filePath != "" {
 		fromFile, err = os.Open(filePath)
 		if err != nil {
 			return nil, err
 		}
+		defer fromFile.Close()
 	} else {
 		fromFile = os.Stdin
 	}
@@ -43,10 +57,43 @@ func ParseJsonFromFileOrStdin(filePath string) (map[string]interface{}, error) {
 	if fi.Size() <= 0 && !pipedStdin {
 		return nil, errors.New("No json input to parse.")
 	}
+	return ioutil.ReadAll(fromFile)
+}
 
-	err = json.NewDecoder(fromFile).Decode(&jsonContent)
+// ParseJson parses a JSON object, reporting malformed input as a validation error.
+func ParseJson(content []byte) (map[string]interface{}, error) {
+	var jsonContent map[string]interface{}
+	err := json.NewDecoder(bytes.NewReader(content)).Decode(&jsonContent)
 	if err != nil {
-		return nil, err
+		return nil, NewValidationError("Could not parse json input: %v", err)
 	}
 	return jsonContent, nil
 }
+
+// ParseYamlOrJson parses a YAML or JSON document, with maps keyed by strings
+// as when decoding JSON.
+func ParseYamlOrJson(content []byte) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(content, &value); err != nil {
+		return nil, err
+	}
+	return stringKeys(value), nil
+}
+
+// stringKeys converts the maps YAML decodes into maps with string keys, as
+// JSON and templates expect.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, child := range v {
+			converted[fmt.Sprintf("%v", key)] = stringKeys(child)
+		}
+		return converted
+	case []interface{}:
+		for i, child := range v {
+			v[i] = stringKeys(child)
+		}
+	}
+	return value
+}
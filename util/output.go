@@ -12,7 +12,7 @@
 //   See the License for the specific language governing permissions and
 //   limitations under the License.
 
-package output
+package util
 
 import (
 	"errors"
@@ -24,6 +24,8 @@ import (
 type OutputFormat struct {
 	// JsonPath specifies a subpath of the output to extract data from
 	JsonPath string
+	// Json requests machine-readable output, including errors.
+	Json bool
 }
 
 func ParseOutputFormat(outputFormat string) (*OutputFormat, error) {
@@ -31,6 +33,9 @@ func ParseOutputFormat(outputFormat string) (*OutputFormat, error) {
 	switch {
 	case outputFormat == "":
 		return format, nil
+	case outputFormat == "json":
+		format.Json = true
+		break
 	case strings.HasPrefix(outputFormat, "jsonpath="):
 		toks := strings.Split(outputFormat, "=")
 		if len(toks) != 2 {
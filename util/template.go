@@ -0,0 +1,241 @@
+// Copyright (c) 2018, Google, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Template engines for rendering input files.
+const (
+	// TemplateVars replaces ${NAME} references in the parsed JSON's strings.
+	TemplateVars = "vars"
+	// TemplateGo renders the file as a Go text/template before parsing it.
+	TemplateGo = "go"
+)
+
+// varRe matches ${NAME} references, and $${NAME} escapes of them. Names are
+// identifiers, so SpEL expressions such as ${ parameters.env } or
+// ${trigger.user} don't match, but SpEL that is a bare identifier, such as
+// ${execution} or ${trigger}, does and must be escaped as $${trigger}.
+var varRe = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadValues merges YAML or JSON values files, later files taking
+// precedence, then applies 'key=value' settings. Dotted keys in settings
+// set nested values, and set values are strings.
+func LoadValues(files, settings []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fileValues, err := ParseYamlOrJson(content)
+		if err != nil {
+			return nil, NewUsageError("could not parse values file %s: %v", file, err)
+		}
+		if fileValues == nil {
+			continue
+		}
+		fileMap, ok := fileValues.(map[string]interface{})
+		if !ok {
+			return nil, NewUsageError("values file %s must hold a map of values", file)
+		}
+		mergeValues(values, fileMap)
+	}
+
+	for _, setting := range settings {
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, NewUsageError("invalid value %q, expected 'key=value'", setting)
+		}
+		keys := strings.Split(parts[0], ".")
+		target := values
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := target[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				target[key] = child
+			}
+			target = child
+		}
+		target[keys[len(keys)-1]] = parts[1]
+	}
+	return values, nil
+}
+
+// mergeValues copies from into to, merging nested maps.
+func mergeValues(to, from map[string]interface{}) {
+	for key, value := range from {
+		fromMap, fromIsMap := value.(map[string]interface{})
+		toMap, toIsMap := to[key].(map[string]interface{})
+		if fromIsMap && toIsMap {
+			mergeValues(toMap, fromMap)
+			continue
+		}
+		to[key] = value
+	}
+}
+
+// RenderGoTemplate executes content as a Go text/template with the values.
+// Strict rendering fails on keys missing from the values, otherwise they
+// render as empty. Templates may use 'toJson' to insert values as JSON and
+// 'default' to fall back on a value.
+func RenderGoTemplate(content []byte, values map[string]interface{}, strict bool) ([]byte, error) {
+	missingKey := "zero"
+	if strict {
+		missingKey = "error"
+	}
+	tmpl, err := template.New("input").Option("missingkey=" + missingKey).Funcs(template.FuncMap{
+		"toJson": func(value interface{}) (string, error) {
+			b, err := json.Marshal(value)
+			return string(b), err
+		},
+		"default": func(fallback, value interface{}) interface{} {
+			if value == nil || value == "" {
+				return fallback
+			}
+			return value
+		},
+		"orEmpty": orEmpty,
+	}).Parse(string(content))
+	if err != nil {
+		return nil, NewValidationError("Could not parse template: %v", err)
+	}
+	if !strict {
+		for _, t := range tmpl.Templates() {
+			if t.Tree != nil {
+				printOrEmpty(t.Tree.Root)
+			}
+		}
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return nil, NewValidationError("Could not render template: %v", err)
+	}
+	return out.Bytes(), nil
+}
+
+// orEmpty renders missing values as nothing instead of "<no value>".
+func orEmpty(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+// printOrEmpty pipes the value of every action under node that prints one
+// through orEmpty.
+func printOrEmpty(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			printOrEmpty(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{parse.NewIdentifier("orEmpty").SetPos(n.Pos)},
+			})
+		}
+	case *parse.IfNode:
+		printOrEmpty(n.List)
+		printOrEmpty(n.ElseList)
+	case *parse.RangeNode:
+		printOrEmpty(n.List)
+		printOrEmpty(n.ElseList)
+	case *parse.WithNode:
+		printOrEmpty(n.List)
+		printOrEmpty(n.ElseList)
+	}
+}
+
+// SubstituteVars replaces ${NAME} references in the strings of a parsed
+// JSON value with top level values, returning the result. A string that is
+// only a reference takes the value's type; values within longer strings are
+// formatted, maps and lists as JSON. $${NAME} is kept as ${NAME}. Undefined
+// references are left alone, or fail strict substitution.
+func SubstituteVars(value interface{}, values map[string]interface{}, strict bool) (interface{}, error) {
+	undefined := map[string]bool{}
+	result := substituteVars(value, values, undefined)
+	if strict && len(undefined) > 0 {
+		names := []string{}
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, NewValidationError("undefined variables: %s", strings.Join(names, ", "))
+	}
+	return result, nil
+}
+
+func substituteVars(value interface{}, values map[string]interface{}, undefined map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		substituted := map[string]interface{}{}
+		for key, child := range v {
+			newKey := fmt.Sprintf("%v", substituteString(key, values, undefined))
+			substituted[newKey] = substituteVars(child, values, undefined)
+		}
+		return substituted
+	case []interface{}:
+		substituted := make([]interface{}, len(v))
+		for i, child := range v {
+			substituted[i] = substituteVars(child, values, undefined)
+		}
+		return substituted
+	case string:
+		return substituteString(v, values, undefined)
+	}
+	return value
+}
+
+func substituteString(s string, values map[string]interface{}, undefined map[string]bool) interface{} {
+	if match := varRe.FindStringSubmatch(s); match != nil && match[0] == s && !strings.HasPrefix(s, "$$") {
+		if value, ok := values[match[1]]; ok {
+			return value
+		}
+	}
+	return varRe.ReplaceAllStringFunc(s, func(reference string) string {
+		if strings.HasPrefix(reference, "$$") {
+			return reference[1:]
+		}
+		name := reference[2 : len(reference)-1]
+		value, ok := values[name]
+		if !ok {
+			undefined[name] = true
+			return reference
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			b, _ := json.Marshal(value)
+			return string(b)
+		}
+		return fmt.Sprintf("%v", value)
+	})
+}